@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// the workloads mirror the benchmark suites of slog, zap and zerolog:
+// a plain message, a message with 10 fields, a logger with 10 accumulated fields and a disabled level.
+// slog is compared in slog_test.go, zap and zerolog are not imported to keep this module dependency free.
+//
+// 基准测试场景参照 slog, zap 及 zerolog 的基准测试: 纯消息, 带 10 个字段的消息, 预先添加 10 个字段的 logger 以及未启用的级别.
+// slog 的对比在 slog_test.go 中, 为避免引入依赖, 未引入 zap 及 zerolog.
+
+var (
+	benchErr  = errors.New("fail")
+	benchTime = time.Date(2023, 4, 20, 16, 50, 22, 0, time.UTC)
+	benchMsg  = "The quick brown fox jumps over the lazy dog"
+)
+
+func benchFields() []any {
+	return []any{
+		F.Int("int", 1),
+		F.Int64("int64", 2),
+		F.Float64("float", 3.0),
+		F.String("string", "four!"),
+		F.Bool("bool", true),
+		F.Time("time", benchTime),
+		F.Duration("duration", time.Second),
+		F.Err(benchErr),
+		F.Any("strings", []string{"a", "b", "c"}),
+		F.Uint64("uint64", 10),
+	}
+}
+
+func benchmarkWorkloads(b *testing.B, logger Logger) {
+	b.Run("Message", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(ctx, benchMsg)
+			}
+		})
+	})
+	b.Run("Printf", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(ctx, "%s: %d %v", benchMsg, 42, true)
+			}
+		})
+	})
+	b.Run("Fields", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.InfoKV(ctx, benchMsg, benchFields()...)
+			}
+		})
+	})
+	b.Run("AccumulatedContext", func(b *testing.B) {
+		logger := logger.WithKV(benchFields()...)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(ctx, benchMsg)
+			}
+		})
+	})
+	b.Run("Disabled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Debug(ctx, benchMsg)
+			}
+		})
+	})
+}
+
+func BenchmarkWorkloads(b *testing.B) {
+	b.Run("Text", func(b *testing.B) {
+		benchmarkWorkloads(b, NewLogger(NewHandler(WithWriter(io.Discard))))
+	})
+	b.Run("JSON", func(b *testing.B) {
+		benchmarkWorkloads(b, NewLogger(NewHandler(WithWriter(io.Discard), WithJSON())))
+	})
+	b.Run("JSON-WithoutCaller", func(b *testing.B) {
+		benchmarkWorkloads(b, NewLogger(NewHandler(WithWriter(io.Discard), WithJSON(), WithoutCaller())))
+	})
+}
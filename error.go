@@ -0,0 +1,163 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"code.gopub.tech/logs/v2/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/redact"
+)
+
+// Err returns a Field with key "error". The output contains the message, the concrete type,
+// the cause chain (errors.Unwrap and errors.Join) and the stack trace if the error exposes it
+// by a `StackTrace()` method or `%+v` format, nested in json and indented after the message in text.
+//
+// 返回键为 "error" 的 Field. 输出内容包括错误消息、具体类型、错误链(errors.Unwrap 及 errors.Join),
+// 以及通过 `StackTrace()` 方法或 `%+v` 格式暴露的调用栈. json 格式嵌套输出, 文本格式在消息之后缩进输出.
+func Err(err error) Field {
+	return F.Err(err)
+}
+
+// maxErrorDepth limits the cause chain, in case of a cycle.
+//
+// 限制错误链的深度, 以防循环引用.
+const maxErrorDepth = 32
+
+// errorInfo is the output form of an error.
+//
+// 错误的输出形式.
+type errorInfo struct {
+	Msg    string       `json:"msg"`
+	Type   string       `json:"type"`
+	Stack  string       `json:"stack,omitempty"`
+	Causes []*errorInfo `json:"causes,omitempty"`
+}
+
+func newErrorInfo(err error, depth int) *errorInfo {
+	info := &errorInfo{
+		Msg:   err.Error(),
+		Type:  fmt.Sprintf("%T", err),
+		Stack: stackOf(err),
+	}
+	if depth >= maxErrorDepth {
+		return info
+	}
+	var causes []error
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		causes = []error{e.Unwrap()}
+	case interface{ Unwrap() []error }: // errors.Join
+		causes = e.Unwrap()
+	}
+	for _, cause := range causes {
+		if cause != nil {
+			info.Causes = append(info.Causes, newErrorInfo(cause, depth+1))
+		}
+	}
+	return info
+}
+
+// stackOf returns the stack trace exposed by the `StackTrace()` method or the `%+v` format.
+//
+// 获取通过 `StackTrace()` 方法或 `%+v` 格式暴露的调用栈.
+func stackOf(err error) string {
+	if m := reflect.ValueOf(err).MethodByName("StackTrace"); m.IsValid() &&
+		m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+		st := m.Call(nil)[0].Interface()
+		if pcs, ok := st.([]uintptr); ok {
+			var sb strings.Builder
+			for _, pc := range pcs {
+				f := caller.GetFrame(pc)
+				sb.WriteString(fmt.Sprintf("%s.%s\n\t%s/%s:%d\n", f.Pkg, f.Fun, f.Path, f.File, f.Line))
+			}
+			return strings.TrimSuffix(sb.String(), "\n")
+		}
+		return strings.TrimPrefix(fmt.Sprintf("%+v", st), "\n") // github.com/pkg/errors.StackTrace
+	}
+	if _, ok := err.(fmt.Formatter); ok {
+		if s := fmt.Sprintf("%+v", err); s != err.Error() {
+			return s
+		}
+	}
+	return ""
+}
+
+// errorOf returns the error if the attr value is an error or an error Field.
+//
+// 如果键值对的值是 error 或错误类型的 Field, 返回该 error.
+func errorOf(v any) (error, bool) {
+	switch v := v.(type) {
+	case Field:
+		if v.Kind == KindError {
+			err, ok := v.any.(error)
+			return err, ok && err != nil
+		}
+	case error:
+		return v, v != nil
+	}
+	return nil, false
+}
+
+// marshalJSON marshal the attr value to json, an error is marshalled as nested object.
+//
+// 将键值对的值转为 json, error 转为嵌套的 json 对象.
+func marshalJSON(v any) ([]byte, error) {
+	if f, ok := v.(Field); ok { // fast path, no need to compact the output
+		return f.MarshalJSON()
+	}
+	if err, ok := v.(error); ok && err != nil {
+		if _, ok := v.(json.Marshaler); !ok {
+			return json.Marshal(newErrorInfo(err, 0))
+		}
+	}
+	return json.Marshal(redact.Struct(v))
+}
+
+// jsonValue is like marshalJSON, but the error is output as a json string `!(BADJSON: err)`, so the line is valid json.
+//
+// 与 marshalJSON 类似, 但出错时输出为 json 字符串 `!(BADJSON: err)`, 以保证输出的行是合法的 json.
+func jsonValue(v any) []byte {
+	b, err := marshalJSON(v)
+	if err != nil {
+		return strconv.AppendQuote(nil, "!(BADJSON: "+err.Error()+")")
+	}
+	return b
+}
+
+// writeErrorDetails write the cause chain and stack trace of the error attrs,
+// an error has neither cause nor stack is skipped since the message is output already.
+//
+// 输出错误的错误链及调用栈. 既没有错误链也没有调用栈的错误会被跳过, 因为消息已经输出过了.
+func writeErrorDetails(sb *bytes.Buffer, prefix string, attrs []any) {
+	for ; len(attrs) > 1; attrs = attrs[2:] {
+		if g, ok := attrs[1].(Group); ok {
+			writeErrorDetails(sb, fmt.Sprintf("%s%v.", prefix, attrs[0]), g)
+			continue
+		}
+		err, ok := errorOf(attrs[1])
+		if !ok {
+			continue
+		}
+		info := newErrorInfo(err, 0)
+		if info.Stack == "" && len(info.Causes) == 0 {
+			continue
+		}
+		writeErrorInfo(sb, fmt.Sprintf("%s%v", prefix, attrs[0]), info, "    ")
+	}
+}
+
+func writeErrorInfo(sb *bytes.Buffer, title string, info *errorInfo, indent string) {
+	sb.WriteString(fmt.Sprintf("%s%s: %s [%s]\n", indent, title, info.Msg, info.Type))
+	if info.Stack != "" {
+		for _, line := range strings.Split(info.Stack, "\n") {
+			sb.WriteString(indent + "    " + line + "\n")
+		}
+	}
+	for _, cause := range info.Causes {
+		writeErrorInfo(sb, "caused by", cause, indent+"    ")
+	}
+}
@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/caller"
+)
+
+// stackError exposes the stack trace by StackTrace method.
+type stackError struct {
+	msg string
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string         { return e.msg }
+func (e *stackError) StackTrace() []uintptr { return e.pcs }
+func (e *stackError) Unwrap() error         { return io.EOF }
+func newStackError(msg string) *stackError {
+	return &stackError{msg: msg, pcs: []uintptr{caller.PC(1)}}
+}
+
+func TestErr(t *testing.T) {
+	joined := joinError{errors.New("e1"), fmt.Errorf("e2: %w", io.EOF)}
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf), WithJSON()))
+	logger.WithError(fmt.Errorf("outer: %w", joined)).Error(ctx, "failed")
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json: %s", buf.String())
+	}
+	b, _ := json.Marshal(m["error"])
+	if want := `{"causes":[{"causes":[{"msg":"e1","type":"*errors.errorString"},` +
+		`{"causes":[{"msg":"EOF","type":"*errors.errorString"}],"msg":"e2: EOF","type":"*fmt.wrapError"}],` +
+		`"msg":"e1\ne2: EOF","type":"logs.joinError"}],"msg":"outer: e1\ne2: EOF","type":"*fmt.wrapError"}`; string(b) != want {
+		t.Errorf("json error = %s\nwant %s", b, want)
+	}
+
+	buf.Reset()
+	logger = NewLogger(NewHandler(WithWriter(&buf)))
+	logger.WithGroup("g").ErrorKV(ctx, "failed", Err(newStackError("boom")), "plain", errors.New("plain"))
+	got := buf.String()
+	for _, want := range []string{
+		" g.error=boom g.plain=plain failed\n",
+		"    g.error: boom [*logs.stackError]\n        code.gopub.tech/logs/v2.TestErr\n        \t",
+		"        caused by: EOF [*errors.errorString]\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("text output should contains %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "g.plain: plain") {
+		t.Errorf("error without cause and stack should not output details:\n%s", got)
+	}
+
+	buf.Reset()
+	logger.ErrorKV(ctx, "failed", Err(formatError{}))
+	if got, want := buf.String(), " error=format failed\n    error: format [logs.formatError]\n"; !strings.Contains(got, want) {
+		t.Errorf("the %%+v of the error should be output after the message, got:\n%s", got)
+	}
+}
+
+// joinError is like the errors.Join of go1.20.
+type joinError []error
+
+func (e joinError) Error() string {
+	var s []string
+	for _, err := range e {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "\n")
+}
+func (e joinError) Unwrap() []error { return e }
+
+// formatError exposes the stack trace by %+v format, like github.com/pkg/errors.
+type formatError struct{}
+
+func (formatError) Error() string { return "format" }
+func (formatError) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		_, _ = io.WriteString(f, "format\nstack line")
+		return
+	}
+	_, _ = io.WriteString(f, "format")
+}
+
+func Test_stackOf(t *testing.T) {
+	if got := stackOf(formatError{}); got != "format\nstack line" {
+		t.Errorf("stackOf() = %q", got)
+	}
+	if got := stackOf(errors.New("x")); got != "" {
+		t.Errorf("stackOf() = %q", got)
+	}
+}
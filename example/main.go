@@ -3,10 +3,10 @@ package main
 import (
 	"context"
 
-	"code.gopub.tech/logs"
-	"code.gopub.tech/logs/pkg/arg"
-	"code.gopub.tech/logs/pkg/kv"
-	"code.gopub.tech/logs/pkg/trie"
+	"code.gopub.tech/logs/v2"
+	"code.gopub.tech/logs/v2/pkg/arg"
+	"code.gopub.tech/logs/v2/pkg/kv"
+	"code.gopub.tech/logs/v2/pkg/trie"
 )
 
 var ctx = context.Background()
@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PanicError is the panic value when output a Panic level log.
+//
+// 输出 Panic 级别日志后抛出的 panic 值.
+//
+//	defer func() {
+//		if e, ok := recover().(*logs.PanicError); ok {
+//			// e.Record
+//		}
+//	}()
+type PanicError struct {
+	Record Record // the keys of Record.Attr are unique 键值对已去重
+}
+
+// Error returns the log message.
+//
+// 返回日志消息.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf(e.Record.Format, e.Record.Args...)
+}
+
+var (
+	exitHooksMu sync.Mutex
+	exitHooks   []func()
+)
+
+// RegisterExitHook register hooks which will be called by all Loggers before exit when output a Fatal log.
+// hooks added by `WithExitHook` are called first.
+//
+// 注册全局的退出钩子, 所有 Logger 输出 Fatal 日志后终止程序前都会调用. `WithExitHook` 添加的钩子会先被调用.
+func RegisterExitHook(hooks ...func()) {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+	exitHooks = append(exitHooks, hooks...)
+}
+
+// runExitHooks call the hooks of the logger and the registered hooks,
+// a panic hook would not stop the others.
+//
+// 依次调用 Logger 的钩子和全局注册的钩子, 某个钩子 panic 不影响其他钩子.
+func runExitHooks(hooks []func()) {
+	exitHooksMu.Lock()
+	hooks = append(hooks[:len(hooks):len(hooks)], exitHooks...)
+	exitHooksMu.Unlock()
+	for _, hook := range hooks {
+		func() {
+			defer func() { _ = recover() }()
+			hook()
+		}()
+	}
+}
@@ -0,0 +1,51 @@
+package logs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFatal(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		code  = -1
+		calls []string
+	)
+	RegisterExitHook(func() { calls = append(calls, "global") })
+	defer func() { exitHooks = nil }()
+	logger := NewLogger(CombineHandlers(NewHandler(WithWriter(&buf)), NewHandler(WithWriter(&buf))),
+		WithExitCode(2),
+		WithExitFunc(func(c int) { code = c }),
+		WithExitHook(func() { calls = append(calls, "hook1") }, func() { panic("ignored") }),
+		WithExitHook(func() { calls = append(calls, "hook2") }),
+	)
+	logger.Fatal(ctx, "fatal %s", "msg")
+	if code != 2 {
+		t.Errorf("exit code = %v, want 2", code)
+	}
+	if got := strings.Join(calls, ","); got != "hook1,hook2,global" {
+		t.Errorf("exit hooks calls = %v", got)
+	}
+	if got := strings.Count(buf.String(), "fatal msg"); got != 2 {
+		t.Errorf("all handlers should output before exit, got %d", got)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf))).With("key", "value").With("key", "dup")
+	defer func() {
+		e, ok := recover().(*PanicError)
+		if !ok {
+			t.Fatalf("recover() should be *PanicError")
+		}
+		if e.Error() != "panic msg" || e.Record.Level != LevelPanic || len(e.Record.Attr) != 2 || e.Record.Attr[1] != "value" {
+			t.Errorf("unexpected PanicError: %#v", e)
+		}
+		if !strings.Contains(buf.String(), "key=value panic msg") {
+			t.Errorf("unexpected output: %v", buf.String())
+		}
+	}()
+	logger.Panic(ctx, "panic %s", "msg")
+}
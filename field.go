@@ -0,0 +1,231 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gopub.tech/logs/v2/pkg/kv"
+	"code.gopub.tech/logs/v2/pkg/redact"
+)
+
+// FieldKind is the kind of the Field value.
+//
+// Field 值的类型.
+type FieldKind int
+
+const (
+	KindAny FieldKind = iota
+	KindString
+	KindInt64
+	KindUint64
+	KindFloat64
+	KindBool
+	KindDuration
+	KindTime
+	KindError
+)
+
+// Field is a typed key-value pair which keeps the kind of the value for the output. use `F` to create a Field.
+// the scalar values are stored in a number or string instead of an any. pass the Fields to the Fields methods
+// (such as `InfoFields`) to keep them unboxed, a Field passed as `...any` to the KV methods is boxed.
+//
+//	logs.InfoFields(ctx, "request done", logs.F.String("method", "GET"), logs.F.Int("status", 200))
+//
+// 带类型的键值对, 保留值的类型用于输出. 使用 `F` 创建.
+// 标量值存储为数字或字符串而不是 any. 传给 Fields 方法(如 `InfoFields`)时不会装箱, 作为 `...any` 传给 KV 方法时会被装箱.
+type Field struct {
+	Key  string
+	Kind FieldKind
+	num  uint64 // int64, uint64, float64, bool, duration
+	str  string // string
+	any  any    // time, error, any
+}
+
+// F is the constructor set of Field.
+//
+// Field 的构造器.
+var F fields
+
+type fields struct{}
+
+func (fields) String(key, value string) Field {
+	return Field{Key: key, Kind: KindString, str: value}
+}
+func (fields) Int(key string, value int) Field {
+	return Field{Key: key, Kind: KindInt64, num: uint64(value)}
+}
+func (fields) Int64(key string, value int64) Field {
+	return Field{Key: key, Kind: KindInt64, num: uint64(value)}
+}
+func (fields) Uint64(key string, value uint64) Field {
+	return Field{Key: key, Kind: KindUint64, num: value}
+}
+func (fields) Float64(key string, value float64) Field {
+	return Field{Key: key, Kind: KindFloat64, num: math.Float64bits(value)}
+}
+func (fields) Bool(key string, value bool) Field {
+	var num uint64
+	if value {
+		num = 1
+	}
+	return Field{Key: key, Kind: KindBool, num: num}
+}
+func (fields) Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Kind: KindDuration, num: uint64(value)}
+}
+func (fields) Time(key string, value time.Time) Field {
+	return Field{Key: key, Kind: KindTime, any: value}
+}
+
+// Err create a Field with key "error", see `Err`.
+//
+// 创建键为 "error" 的 Field, 参见 `Err`.
+func (fields) Err(err error) Field {
+	return Field{Key: "error", Kind: KindError, any: err}
+}
+func (fields) Any(key string, value any) Field {
+	return Field{Key: key, Kind: KindAny, any: value}
+}
+
+// Value returns the value of the Field as any.
+//
+// 以 any 类型返回 Field 的值.
+func (f Field) Value() any {
+	switch f.Kind {
+	case KindString:
+		return f.str
+	case KindInt64:
+		return int64(f.num)
+	case KindUint64:
+		return f.num
+	case KindFloat64:
+		return math.Float64frombits(f.num)
+	case KindBool:
+		return f.num == 1
+	case KindDuration:
+		return time.Duration(f.num)
+	default:
+		return f.any
+	}
+}
+
+// String returns the text form of the value, used by text output.
+//
+// 返回值的文本形式, 用于文本格式输出.
+func (f Field) String() string {
+	switch f.Kind {
+	case KindString:
+		return f.str
+	case KindInt64:
+		return strconv.FormatInt(int64(f.num), 10)
+	case KindUint64:
+		return strconv.FormatUint(f.num, 10)
+	case KindFloat64:
+		return strconv.FormatFloat(math.Float64frombits(f.num), 'g', -1, 64)
+	case KindBool:
+		return strconv.FormatBool(f.num == 1)
+	case KindDuration:
+		return time.Duration(f.num).String()
+	case KindTime:
+		return f.any.(time.Time).Format(time.RFC3339Nano)
+	case KindError: // 错误链及调用栈在消息之后输出, 参见 writeErrorDetails
+		if err, ok := f.any.(error); ok && err != nil {
+			return err.Error()
+		}
+		return fmt.Sprint(f.any)
+	default:
+		return fmt.Sprintf("%+v", f.any)
+	}
+}
+
+// MarshalJSON returns the json form of the value, used by json output.
+//
+// 返回值的 json 形式, 用于 json 格式输出.
+func (f Field) MarshalJSON() ([]byte, error) {
+	switch f.Kind {
+	case KindInt64, KindUint64, KindBool:
+		return []byte(f.String()), nil
+	case KindFloat64:
+		if v := math.Float64frombits(f.num); math.IsInf(v, 0) || math.IsNaN(v) {
+			return []byte(strconv.Quote(f.String())), nil // json 不支持 NaN, Inf
+		}
+		return []byte(f.String()), nil
+	case KindDuration:
+		return json.Marshal(f.String())
+	case KindError:
+		if f.any == nil {
+			return []byte("null"), nil
+		}
+		return marshalJSON(f.any)
+	default:
+		return json.Marshal(redact.Struct(f.Value()))
+	}
+}
+
+// badKey is the key of a value which has no key.
+//
+// 缺少键的值所使用的键.
+const badKey = kv.BadKey
+
+// fieldAttrs convert the Fields to key-value pairs.
+//
+// 将 Field 转换为键值对.
+func fieldAttrs(fs []Field) []any {
+	if len(fs) == 0 {
+		return nil
+	}
+	attrs := make([]any, 0, len(fs)*2)
+	for _, f := range fs {
+		attrs = append(attrs, f.Key, f)
+	}
+	return attrs
+}
+
+// callAttrs convert the kvs or the Fields of a log call to key-value pairs.
+//
+// 将打印日志时传入的 kvs 或 Field 转换为键值对.
+func callAttrs(kvs []any, fs []Field) []any {
+	if len(fs) > 0 {
+		return fieldAttrs(fs)
+	}
+	return attrsOf(kvs)
+}
+
+// attrsOf convert the kvs to key-value pairs, kvs can be Field or key-value pairs,
+// the value which has no key would use "!BADKEY" as the key.
+//
+// 将 kvs 转换为键值对. kvs 中可以是 Field 或键值对, 缺少键的值会以 "!BADKEY" 作为键.
+func attrsOf(kvs []any) []any {
+	if len(kvs) == 0 {
+		return nil
+	}
+	attrs := make([]any, 0, len(kvs)+1)
+	for len(kvs) > 0 {
+		if f, ok := kvs[0].(Field); ok {
+			attrs = append(attrs, f.Key, f)
+			kvs = kvs[1:]
+			continue
+		}
+		if len(kvs) == 1 {
+			attrs = append(attrs, badKey, kvs[0])
+			break
+		}
+		attrs = append(attrs, kvs[0], kvs[1])
+		kvs = kvs[2:]
+	}
+	return attrs
+}
+
+// escapeFormat escape the msg so it can be used as a format.
+//
+// 转义 msg 使其可以作为格式化字符串使用.
+func escapeFormat(msg string) string {
+	if strings.IndexByte(msg, '%') < 0 {
+		return msg
+	}
+	return strings.ReplaceAll(msg, "%", "%%")
+}
@@ -0,0 +1,93 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestField(t *testing.T) {
+	ts := time.Date(2023, 4, 20, 16, 50, 22, 0, time.UTC)
+	tests := []struct {
+		name     string
+		f        Field
+		wantStr  string
+		wantJSON string
+	}{
+		{"string", F.String("k", `a"b`), `a"b`, `"a\"b"`},
+		{"int", F.Int("k", -1), "-1", "-1"},
+		{"int64", F.Int64("k", math.MaxInt64), "9223372036854775807", "9223372036854775807"},
+		{"uint64", F.Uint64("k", math.MaxUint64), "18446744073709551615", "18446744073709551615"},
+		{"float64", F.Float64("k", 1.5), "1.5", "1.5"},
+		{"float64-nan", F.Float64("k", math.NaN()), "NaN", `"NaN"`},
+		{"bool", F.Bool("k", true), "true", "true"},
+		{"duration", F.Duration("k", 1500*time.Millisecond), "1.5s", `"1.5s"`},
+		{"time", F.Time("k", ts), "2023-04-20T16:50:22Z", `"2023-04-20T16:50:22Z"`},
+		{"err", F.Err(errors.New("oops")), "oops", `{"msg":"oops","type":"*errors.errorString"}`},
+		{"err-nil", F.Err(nil), "<nil>", `null`},
+		{"any", F.Any("k", []int{1, 2}), "[1 2]", `[1,2]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.String(); got != tt.wantStr {
+				t.Errorf("String() = %v, want %v", got, tt.wantStr)
+			}
+			if got, err := json.Marshal(tt.f); err != nil || string(got) != tt.wantJSON {
+				t.Errorf("MarshalJSON() = %s, %v, want %v", got, err, tt.wantJSON)
+			}
+		})
+	}
+	if F.Int("k", 1).Value() != int64(1) || F.Bool("k", false).Value() != false || F.String("k", "v").Value() != "v" {
+		t.Errorf("Value() fail")
+	}
+}
+
+func TestLogKV(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf))).WithKV("a", 1, F.Bool("b", true))
+	logger.InfoKV(ctx, "100% done", F.String("method", "GET"), "status", 200, "lonely")
+	if got := buf.String(); !strings.HasSuffix(got, " a=1 b=true method=GET status=200 !BADKEY=lonely 100% done\n") {
+		t.Errorf("unexpected text output: %s", got)
+	}
+
+	buf.Reset()
+	logger = NewLogger(NewHandler(WithWriter(&buf), WithJSON()))
+	logger.WarnKV(ctx, "msg", F.Int("n", 1), F.Duration("cost", time.Second))
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json: %s", buf.String())
+	}
+	if m["n"] != float64(1) || m["cost"] != "1s" || m["msg"] != "msg" || m["level"] != "WARN" {
+		t.Errorf("unexpected json output: %s", buf.String())
+	}
+}
+
+func TestLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf))).WithKV("a", 1)
+	logger.InfoFields(ctx, "100% done", F.String("method", "GET"), F.Int("status", 200))
+	if got := buf.String(); !strings.HasSuffix(got, " a=1 method=GET status=200 100% done\n") {
+		t.Errorf("unexpected text output: %s", got)
+	}
+
+	buf.Reset()
+	logger.DebugFields(ctx, "disabled", F.Int("n", 1))
+	if buf.Len() != 0 {
+		t.Errorf("disabled log should not be output: %s", buf.String())
+	}
+
+	buf.Reset()
+	logger = NewLogger(NewHandler(WithWriter(&buf), WithJSON()))
+	logger.WarnFields(ctx, "msg", F.Int("n", 1), F.Duration("cost", time.Second))
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json: %s", buf.String())
+	}
+	if m["n"] != float64(1) || m["cost"] != "1s" || m["msg"] != "msg" || m["level"] != "WARN" {
+		t.Errorf("unexpected json output: %s", buf.String())
+	}
+}
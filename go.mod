@@ -1,4 +1,4 @@
-module code.gopub.tech/logs
+module code.gopub.tech/logs/v2
 
 go 1.20
 
@@ -0,0 +1,98 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Group is a group of key-value pairs used as a value of Record.Attr,
+// it is rendered as `group.key=value` in text and `{"group":{"key":value}}` in json.
+// see `Logger.WithGroup`.
+//
+// 一组键值对, 作为 Record.Attr 中的值使用.
+// 文本格式输出为 `group.key=value`, json 格式输出为 `{"group":{"key":value}}`. 参见 `Logger.WithGroup`.
+type Group []any
+
+// String returns the flatten text form of the group, such as `key=value inner.key=value`.
+//
+// 返回展开后的文本形式, 如 `key=value inner.key=value`.
+func (g Group) String() string {
+	var sb bytes.Buffer
+	writeTextAttrs(&sb, "", g)
+	return strings.TrimSuffix(sb.String(), " ")
+}
+
+// MarshalJSON returns the nested json object of the group.
+//
+// 返回嵌套的 json 对象.
+func (g Group) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i+1 < len(g); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(fmt.Sprintf("%v", g[i]))
+		buf.Write(key)
+		buf.WriteByte(':')
+		b, err := marshalJSON(g[i+1])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeTextAttrs write the attrs as `prefix.key=value `, the Group value is flatten.
+//
+// 以 `prefix.key=value ` 形式输出键值对, 值为 Group 时展开输出.
+func writeTextAttrs(sb *bytes.Buffer, prefix string, attrs []any) {
+	for len(attrs) > 1 {
+		if g, ok := attrs[1].(Group); ok {
+			writeTextAttrs(sb, fmt.Sprintf("%s%v.", prefix, attrs[0]), g)
+		} else {
+			fmt.Fprintf(sb, "%s%v=%v ", prefix, attrs[0], attrs[1])
+		}
+		attrs = attrs[2:]
+	}
+}
+
+// mapAttrs returns the attrs with the values replaced by fn, the values in groups are replaced too.
+// the attrs are copied on the first change, since they may be shared with the Logger.
+//
+// 返回值被 fn 替换后的键值对, 分组中的值也会被替换. 键值对可能与 Logger 共享, 因此在第一次替换时复制.
+func mapAttrs(attrs []any, fn func(key, value any) (any, bool)) ([]any, bool) {
+	var result []any
+	for i := 0; i+1 < len(attrs); i += 2 {
+		value, changed := fn(attrs[i], attrs[i+1])
+		if g, ok := value.(Group); ok {
+			if group, ok := mapAttrs(g, fn); ok {
+				value, changed = Group(group), true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if result == nil {
+			result = append(make([]any, 0, len(attrs)), attrs...)
+		}
+		result[i+1] = value
+	}
+	if result == nil {
+		return attrs, false
+	}
+	return result, true
+}
+
+// openGroup is a group opened by `Logger.WithGroup`.
+//
+// 通过 `Logger.WithGroup` 开启的分组.
+type openGroup struct {
+	name  string
+	attrs []any
+	seq   uint64 // sequence number of the last added attrs, only for NewestWins
+}
@@ -0,0 +1,54 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLogger_WithGroup(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf))).With("a", 1).WithGroup("http").With("method", "GET")
+	logger.WithGroup("req").WithGroup("").InfoKV(ctx, "msg", "path", "/api")
+	logger.WithGroup("empty").Info(ctx, "no attrs")
+	NewLogger(NewHandler(WithWriter(&buf))).WithGroup("empty").Info(ctx, "empty group")
+	got := buf.String()
+	for _, want := range []string{
+		" a=1 http.method=GET http.req.path=/api msg\n",
+		" a=1 http.method=GET no attrs\n",
+		"group_test.go:15 empty group\n", // no attrs
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output should contains %q, got: %s", want, got)
+		}
+	}
+
+	buf.Reset()
+	logger = NewLogger(NewHandler(WithWriter(&buf), WithJSON())).WithGroup("http").With("method", "GET")
+	logger.WithGroup("req").InfoKV(ctx, "msg", "path", "/api")
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"http":{"method":"GET","req":{"path":"/api"}}`) {
+		t.Errorf("unexpected json output: %s", buf.String())
+	}
+	if got := formatRecord("%X", &Record{Attr: []any{"k", "v", "g", Group{"a", 1, "b", Group{"c", 2}}}}); got != "k=v g.a=1 g.b.c=2" {
+		t.Errorf("formatRecord() = %q", got)
+	}
+}
+
+func TestLogger_WithGroupBranch(t *testing.T) {
+	// With after WithGroup should not modify the parent logger
+	var buf bytes.Buffer
+	parent := NewLogger(NewHandler(WithWriter(&buf))).WithGroup("g").With("a", 1)
+	parent.With("b", 2)
+	parent.With("c", 3).Info(ctx, "msg")
+	if got := buf.String(); !strings.Contains(got, " g.a=1 g.c=3 msg") {
+		t.Errorf("unexpected output: %s", got)
+	}
+	if _, err := json.Marshal(Group{"bad", func() {}}); err == nil {
+		t.Errorf("Group with bad value should fail")
+	}
+}
@@ -1,15 +1,17 @@
 package logs
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"strconv"
 	"strings"
+	"sync"
 
-	"code.gopub.tech/logs/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/kv"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -17,13 +19,108 @@ import (
 //
 // 处理日志的接口.
 type Handler interface {
-	// Output output the log Record.
+	// Output output the log Record. the keys of Record.Attr may be duplicate, the first one should win,
+	// see kv.Uniq and `WithDuplicateAttrs`.
 	//
-	// 输出日志.
+	// 输出日志. Record.Attr 中可能有重复的键, 应以第一个为准, 参见 kv.Uniq 及 `WithDuplicateAttrs`.
 	Output(Record)
 	Enable(level Level, pc uintptr) bool
 }
 
+// ContextHandler is an optional interface of Handler,
+// it decides whether a log should be output with the ctx and the logger name of the log.
+//
+// 可选的处理器接口, 判断日志是否需要输出时可以使用日志的 ctx 及 logger 名称.
+type ContextHandler interface {
+	Handler
+	EnableContext(ctx context.Context, name string, level Level, pc uintptr) bool
+}
+
+// enableContext use EnableContext if the Handler implements ContextHandler.
+//
+// 如果处理器实现了 ContextHandler 则使用 EnableContext 判断.
+func enableContext(h Handler, ctx context.Context, name string, level Level, pc uintptr) bool {
+	if ch, ok := h.(ContextHandler); ok {
+		return ch.EnableContext(ctx, name, level, pc)
+	}
+	return h.Enable(level, pc)
+}
+
+// CallerHandler is an optional interface of Handler, it reports whether the Handler needs the log position (Record.PC).
+// the Logger skips getting the caller if the Handler does not need it, see `WithoutCaller`.
+//
+// 可选的处理器接口, 返回处理器是否需要打印日志的位置(Record.PC).
+// 如果处理器不需要, Logger 将不再获取调用位置, 参见 `WithoutCaller`.
+type CallerHandler interface {
+	Handler
+	NeedCaller() bool
+}
+
+// needCaller returns true if the Handler does not implement CallerHandler.
+//
+// 处理器未实现 CallerHandler 时返回 true.
+func needCaller(h Handler) bool {
+	if ch, ok := h.(CallerHandler); ok {
+		return ch.NeedCaller()
+	}
+	return true
+}
+
+// levelCallerHandler is implemented by the handlers of this package, it reports whether the log position is needed
+// to decide the level of the named logger. the Logger checks the level before getting the caller if not needed.
+//
+// 由本包的处理器实现, 返回判断该名称的 logger 的级别时是否需要日志位置. 不需要时 Logger 会先判断级别再获取调用位置.
+type levelCallerHandler interface {
+	levelNeedCaller(name string) bool
+}
+
+// levelNeedCaller returns true if the Handler does not implement levelCallerHandler.
+//
+// 处理器未实现 levelCallerHandler 时返回 true.
+func levelNeedCaller(h Handler, name string) bool {
+	if lh, ok := h.(levelCallerHandler); ok {
+		return lh.levelNeedCaller(name)
+	}
+	return true
+}
+
+// checkedHandler is implemented by the handlers of this package, the Logger calls outputChecked instead of Output
+// after it has checked the level by this Handler, so the level is not checked again.
+// it is unexported so the handlers of other packages always check the level in Output.
+//
+// 由本包的处理器实现, Logger 已使用该处理器判断过级别时调用 outputChecked 而不是 Output, 避免重复判断.
+// 不导出该接口, 因此其他包的处理器在 Output 中总会判断级别.
+type checkedHandler interface {
+	outputChecked(Record)
+}
+
+// outputChecked output the Record whose level is checked by the Handler.
+//
+// 输出已由该处理器判断过级别的日志.
+func outputChecked(h Handler, r Record) {
+	if ch, ok := h.(checkedHandler); ok {
+		ch.outputChecked(r)
+		return
+	}
+	h.Output(r)
+}
+
+// outputForced output the Record without checking the level by the handlers of this package,
+// it is used when the level is decided by the caller, such as the HandlerOptions.Level of SlogHandler.
+// the handlers of other packages still check the level in Output.
+//
+// 输出日志, 本包的处理器不再判断级别, 用于级别由调用方决定的场景, 如 SlogHandler 的 HandlerOptions.Level.
+// 其他包的处理器仍会在 Output 中判断级别.
+func outputForced(h Handler, r Record) {
+	if s, ok := h.(Handlers); ok {
+		for _, h := range s {
+			outputForced(h, r)
+		}
+		return
+	}
+	outputChecked(h, r)
+}
+
 type Handlers []Handler
 
 func (s Handlers) Output(r Record) {
@@ -32,6 +129,15 @@ func (s Handlers) Output(r Record) {
 	}
 }
 
+// outputChecked 已判断的是任一处理器启用, 多个处理器时需要各自判断
+func (s Handlers) outputChecked(r Record) {
+	if len(s) == 1 {
+		outputChecked(s[0], r)
+		return
+	}
+	s.Output(r)
+}
+
 func (s Handlers) Enable(level Level, pc uintptr) bool {
 	for _, h := range s {
 		if h.Enable(level, pc) {
@@ -41,6 +147,33 @@ func (s Handlers) Enable(level Level, pc uintptr) bool {
 	return false
 }
 
+func (s Handlers) EnableContext(ctx context.Context, name string, level Level, pc uintptr) bool {
+	for _, h := range s {
+		if enableContext(h, ctx, name, level, pc) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Handlers) levelNeedCaller(name string) bool {
+	for _, h := range s {
+		if levelNeedCaller(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Handlers) NeedCaller() bool {
+	for _, h := range s {
+		if needCaller(h) {
+			return true
+		}
+	}
+	return false
+}
+
 func CombineHandlers(h ...Handler) Handler {
 	return Handlers(h)
 }
@@ -97,9 +230,9 @@ func WithColor() Option { return func(h *handler) { h.colorMode = 1 } }
 // 禁用日志颜色.
 func WithNoColor() Option { return func(h *handler) { h.colorMode = 2 } }
 
-// WithName set the logger name.
+// WithName set the logger name. the name set by `Logger.Named` takes precedence.
 //
-// 设置 logger 名称.
+// 设置 logger 名称. `Logger.Named` 设置的名称优先.
 func WithName(name string) Option { return func(h *handler) { h.name = name } }
 
 // WithLevel set the default log level.
@@ -124,14 +257,33 @@ func WithLevels(levelConfig LevelProvider) Option {
 	return func(h *handler) { h.levelConfig = levelConfig }
 }
 
+// WithoutLevelOverride ignore the level set on ctx by `WithLevelOverride`.
+// e.g. a handler only outputs error logs to a file should not output debug logs of a request.
+//
+// 忽略 `WithLevelOverride` 在 ctx 上设置的级别. 如仅输出错误日志到文件的处理器, 不应因某个请求开启了 Debug 而输出调试日志.
+func WithoutLevelOverride() Option { return func(h *handler) { h.noOverride = true } }
+
+// WithoutCaller tells the Logger not to get the log position, which saves the cost of runtime.Callers on hot paths.
+// the source info is output as `?` and the `WithLevels` option can not search the level by package name.
+//
+// 不获取打印日志的位置, 以节省热点路径上 runtime.Callers 的开销.
+// 源码位置会输出为 `?`, `WithLevels` 选项也无法按包名查找级别.
+func WithoutCaller() Option { return func(h *handler) { h.noCaller = true } }
+
+// WithDuplicateAttrs keep the duplicate keys of the attrs, all of them are output in the order of
+// `AttrPrecedence`, useful for audit logs. note that the json output has duplicate keys then.
+//
+// 保留重复的键, 按 `AttrPrecedence` 的顺序全部输出, 可用于审计日志. 注意此时 json 输出中会有重复的键.
+func WithDuplicateAttrs() Option { return func(h *handler) { h.keepDup = true } }
+
 // FormatFun format a log Record to string. the return string should ends with a '\n' as usual.
 //
 // 格式化一条日志记录. 通常, 返回的字符串应当以换行 '\n' 符结尾.
 type FormatFun func(*Record) string
 
-// WithFormatFun set the format function.
+// WithFormatFun set the format function. like slog, the source should be skipped if Record.PC is 0.
 //
-// 设置格式化日志函数.
+// 设置格式化日志函数. 与 slog 一致, Record.PC 为 0 时应不输出源码位置.
 func WithFormatFun(fn FormatFun) Option { return func(h *handler) { h.format = fn } }
 
 // WithJSON output the log as json format.
@@ -161,6 +313,7 @@ func WithJson(b bool) Option {
 // [实验性]设置日志格式. 使用正则表达式实现, 性能可能不是很好.
 //
 //	placeholder     args        describe
+//	%logger         N/A       print the logger name
 //	%n or %N        N/A       print a newline
 //	%l or %level    (-?\d+)?  print the log level; the args set print width
 //	%F or %FILE     N/A       print the file name
@@ -199,21 +352,46 @@ func WithFormat(format string) Option {
 //
 // Handler 接口的一个简单实现.
 type handler struct {
-	io.Writer                  // output dest           输出目的地
-	colorMode    int           // colorMode 0=auto 1=forceColor 2=disableColor
-	name         string        // logger name
-	defaultLevel Level         // default level         默认级别
-	levelConfig  LevelProvider // level provider        为不同包设置不同级别
-	format       FormatFun     // format Record to string
+	io.Writer                       // output dest           输出目的地
+	colorMode    int                // colorMode 0=auto 1=forceColor 2=disableColor
+	name         string             // logger name
+	defaultLevel Level              // default level         默认级别
+	levelConfig  LevelProvider      // level provider        为不同包设置不同级别
+	format       FormatFun          // format Record to string
+	noOverride   bool               // ignore level override on ctx 忽略 ctx 上的级别
+	stack        *stackConfig       // capture call stack          捕获调用栈
+	path         PathFunc           // rewrite the source path     改写源码路径
+	noCaller     bool               // do not need the log position 不需要日志位置
+	keepDup      bool               // keep duplicate attrs        保留重复的键值对
+	extractors   []ContextExtractor // extract attrs from ctx  从 ctx 中提取键值对
+	redact       *redactConfig      // mask sensitive data     敏感数据脱敏
 }
 
 // Output output the log Record to dest.
 //
 // 输出日志.
 func (h *handler) Output(r Record) {
-	if !h.Enable(r.Level, r.PC) {
+	if !h.EnableContext(r.Ctx, r.Name, r.Level, r.PC) {
 		return
 	}
+	h.outputChecked(r)
+}
+
+func (h *handler) outputChecked(r Record) {
+	if h.stack != nil && r.Stack == nil && r.Level >= h.stack.level {
+		r.Stack = h.stack.capture(r.PC)
+	}
+	r.path = h.path
+	if len(h.extractors) > 0 {
+		r.Attr = concatAttrs(r.Attr, extract(r.Ctx, h.extractors))
+	}
+	if !h.keepDup {
+		r.Attr = kv.Uniq(r.Attr)
+	}
+	r.Attr = resolveAttrs(r.Attr)
+	if h.redact != nil {
+		h.redact.apply(&r)
+	}
 	if h.format == nil {
 		h.format = toString
 	}
@@ -222,21 +400,21 @@ func (h *handler) Output(r Record) {
 		msg = defaultColor(r.Level, msg)
 	}
 	_, _ = h.Write([]byte(msg))
-	if r.Level >= LevelFatal {
-		os.Exit(int(r.Level))
-	}
-	if r.Level >= LevelPanic {
-		panic(msg)
-	}
 }
 
 // enable return true if the Record should be output.
 //
 // 判断给定日志是否应当输出. 如果打印的日志级别(如给定日志是 Info 级别)不低于配置的日志级别(如配置 Debug 及以上级别均需打印)说明可以输出.
 func (h *handler) Enable(level Level, pc uintptr) bool {
+	return h.enable("", level, pc)
+}
+
+func (h *handler) enable(name string, level Level, pc uintptr) bool {
 	if h.levelConfig != nil {
-		var name = h.name // 默认用 logger name
-		if name == "" {   // 如果没有指定 logger name
+		if name == "" { // 没有 logger 名称
+			name = h.name // 默认用 handler name
+		}
+		if name == "" { // 如果没有指定 name
 			frame := caller.GetFrame(pc)
 			name = frame.Pkg // 就用包名
 		}
@@ -246,6 +424,29 @@ func (h *handler) Enable(level Level, pc uintptr) bool {
 	return level >= h.defaultLevel
 }
 
+// EnableContext is like Enable, but the level set on ctx by `WithLevelOverride` takes precedence,
+// and the logger name (see `Logger.Named`) is used to search the level before the handler name.
+//
+// 与 Enable 类似, 但优先使用 `WithLevelOverride` 在 ctx 上设置的级别,
+// 并且优先使用 logger 名称(参见 `Logger.Named`)而非处理器名称查找级别.
+func (h *handler) EnableContext(ctx context.Context, name string, level Level, pc uintptr) bool {
+	if !h.noOverride {
+		if minLevel, ok := LevelOverride(ctx); ok {
+			return level >= minLevel
+		}
+	}
+	return h.enable(name, level, pc)
+}
+
+func (h *handler) NeedCaller() bool { return !h.noCaller }
+
+// levelNeedCaller the package name of the log position is used to search the level if no name is given.
+//
+// 未指定名称时使用日志位置的包名查找级别.
+func (h *handler) levelNeedCaller(name string) bool {
+	return h.levelConfig != nil && name == "" && h.name == ""
+}
+
 func (h *handler) color() bool {
 	return h.colorMode == 1 || (h.colorMode == 0 && isTerminal(h.Writer))
 }
@@ -255,55 +456,122 @@ var (
 	timeFormatOnText = "2006-01-02T15:04:05.000-07:00"
 )
 
+// bufPool is the pool of the buffers used by the formatters.
+//
+// 格式化日志时使用的缓冲池.
+var bufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// maxPooledBuffer is the max capacity of the buffer put back to the pool, a larger one is dropped.
+//
+// 放回缓冲池的最大容量, 更大的会被丢弃.
+const maxPooledBuffer = 64 << 10
+
+func getBuffer() *bytes.Buffer { return bufPool.Get().(*bytes.Buffer) }
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufPool.Put(buf)
+}
+
+// message returns the formatted message, the format is returned directly if there is nothing to format.
+//
+// 返回格式化后的消息, 无需格式化时直接返回 format.
+func message(r *Record) string {
+	if len(r.Args) == 0 && strings.IndexByte(r.Format, '%') < 0 {
+		return r.Format
+	}
+	return fmt.Sprintf(r.Format, r.Args...)
+}
+
 func toJSON(r *Record) string {
-	var sb strings.Builder
+	sb := getBuffer()
+	defer putBuffer(sb)
+	var tmp [64]byte
 	// {"time":"","level":"","pkg":"","fun":"","path":"","file":"","line":0,"msg":"","key":"value"}
 	sb.WriteString(`{"ts":`)
-	sb.WriteString(strconv.FormatInt(int64(r.Time.UnixNano()), 10))
+	sb.Write(strconv.AppendInt(tmp[:0], r.Time.UnixNano(), 10))
 	sb.WriteString(`,"time":"`)
-	sb.WriteString(r.Time.Format(timeFormatOnJSON))
+	sb.Write(r.Time.AppendFormat(tmp[:0], timeFormatOnJSON))
 	sb.WriteString(`","level":`)
-	sb.WriteString(strconv.Quote(r.Level.String()))
-	sb.WriteString(`,"pkg":"`)
-	frame := caller.GetFrame(r.PC)
-	sb.WriteString(frame.Pkg)
-	sb.WriteString(`","fun":"`)
-	sb.WriteString(frame.Fun)
-	sb.WriteString(`","path":"`)
-	sb.WriteString(frame.Path)
-	sb.WriteString(`","file":"`)
-	sb.WriteString(frame.File)
-	sb.WriteString(`","line":`)
-	sb.WriteString(strconv.FormatInt(int64(frame.Line), 10))
+	sb.Write(strconv.AppendQuote(tmp[:0], r.Level.String()))
+	if r.Name != "" {
+		sb.WriteString(`,"logger":`)
+		sb.WriteString(strconv.Quote(r.Name))
+	}
+	if r.PC != 0 { // 未知位置或不输出源码信息, 参见 slog.HandlerOptions.AddSource
+		sb.WriteString(`,"pkg":"`)
+		frame := r.Frame()
+		sb.WriteString(frame.Pkg)
+		sb.WriteString(`","fun":"`)
+		sb.WriteString(frame.Fun)
+		sb.WriteString(`","path":"`)
+		sb.WriteString(frame.Path)
+		sb.WriteString(`","file":"`)
+		sb.WriteString(frame.File)
+		sb.WriteString(`","line":`)
+		sb.Write(strconv.AppendInt(tmp[:0], int64(frame.Line), 10))
+	}
 	attrs := r.Attr
 	for len(attrs) > 1 {
-		key := fmt.Sprintf("%v", attrs[0])
-		value, _ := json.Marshal(attrs[1])
-		sb.WriteString(fmt.Sprintf(",%q:%s", key, value))
+		sb.WriteByte(',')
+		if key, ok := attrs[0].(string); ok {
+			sb.WriteString(strconv.Quote(key))
+		} else {
+			sb.WriteString(strconv.Quote(fmt.Sprintf("%v", attrs[0])))
+		}
+		sb.WriteByte(':')
+		sb.Write(jsonValue(attrs[1]))
 		attrs = attrs[2:]
 	}
+	if len(r.Stack) > 0 {
+		sb.WriteString(`,"stack":`)
+		writeStackJSON(sb, r.Stack, r.path)
+	}
 	sb.WriteString(`,"msg":`)
-	msg := fmt.Sprintf(r.Format, r.Args...)
-	sb.WriteString(strconv.Quote(msg))
+	sb.WriteString(strconv.Quote(message(r)))
 	sb.WriteString("}\n")
 	return sb.String()
 }
 
 func toString(r *Record) string {
-	time := r.Time.Format(timeFormatOnText)
-	frame := caller.GetFrame(r.PC)
-	var sb strings.Builder
-	// 2006-01-02T15:04:05.000-07:00 NOTICE pkg.fun path/file.go:11 key=value Message
-	sb.WriteString(fmt.Sprintf("%s %-5s %s.%s %s/%s:%d ",
-		time, r.Level, ifEmpty(frame.Pkg, "?"), ifEmpty(frame.Fun, "?"),
-		ifEmpty(frame.Path, "?"), ifEmpty(frame.File, "???"), frame.Line))
-	attrs := r.Attr
-	for len(attrs) > 1 {
-		sb.WriteString(fmt.Sprintf("%v=%v ", attrs[0], attrs[1]))
-		attrs = attrs[2:]
+	sb := getBuffer()
+	defer putBuffer(sb)
+	var tmp [64]byte
+	// 2006-01-02T15:04:05.000-07:00 NOTICE [name] pkg.fun path/file.go:11 key=value Message
+	sb.Write(r.Time.AppendFormat(tmp[:0], timeFormatOnText))
+	sb.WriteByte(' ')
+	level := r.Level.String()
+	sb.WriteString(level)
+	for i := len(level); i < 5; i++ { // %-5s
+		sb.WriteByte(' ')
+	}
+	sb.WriteByte(' ')
+	if r.Name != "" {
+		sb.WriteString("[" + r.Name + "] ")
+	}
+	if r.PC != 0 { // 未知位置或不输出源码信息, 参见 slog.HandlerOptions.AddSource
+		frame := r.Frame()
+		sb.WriteString(ifEmpty(frame.Pkg, "?"))
+		sb.WriteByte('.')
+		sb.WriteString(ifEmpty(frame.Fun, "?"))
+		sb.WriteByte(' ')
+		sb.WriteString(ifEmpty(frame.Path, "?"))
+		sb.WriteByte('/')
+		sb.WriteString(ifEmpty(frame.File, "???"))
+		sb.WriteByte(':')
+		sb.Write(strconv.AppendInt(tmp[:0], int64(frame.Line), 10))
+		sb.WriteByte(' ')
+	}
+	writeTextAttrs(sb, "", r.Attr)
+	sb.WriteString(message(r))
+	sb.WriteByte('\n')
+	writeErrorDetails(sb, "", r.Attr)
+	if len(r.Stack) > 0 {
+		writeStackText(sb, r.Stack, r.path)
 	}
-	sb.WriteString(fmt.Sprintf(r.Format, r.Args...))
-	sb.WriteRune('\n')
 	return sb.String()
 }
 
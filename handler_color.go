@@ -43,24 +43,21 @@ func init() { // if the handler's colorMode=force we need enable color
 	}
 }
 
+// defaultColor colors the msg with the color of the level,
+// if the level has no color, the color of the nearest lower level is used. see `LevelColor`.
+//
+// 使用级别对应的颜色输出, 如果级别没有设置颜色, 使用最近的较低级别的颜色.
 func defaultColor(level Level, msg string) string {
-	switch {
-	case level >= LevelFatal:
-		return fatalColor.Sprint(msg)
-	case level >= LevelPanic:
-		return panicColor.Sprint(msg)
-	case level >= LevelError:
-		return errorColor.Sprint(msg)
-	case level >= LevelWarn:
-		return warnColor.Sprint(msg)
-	case level >= LevelNotice:
-		return noticeColor.Sprint(msg)
-	case level >= LevelInfo:
-		return infoColor.Sprint(msg)
-	case level >= LevelDebug:
-		return debugColor.Sprint(msg)
-	case level < LevelDebug:
-		return traceColor.Sprint(msg)
+	infos := registeredLevels()
+	for i := len(infos) - 1; i >= 0; i-- {
+		if infos[i].level <= level && infos[i].color != nil {
+			return infos[i].color.Sprint(msg)
+		}
+	}
+	for _, info := range infos { // lower than all levels
+		if info.color != nil {
+			return info.color.Sprint(msg)
+		}
 	}
 	return msg
 }
@@ -1,13 +1,10 @@
 package logs
 
 import (
-	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
-
-	"code.gopub.tech/logs/pkg/caller"
 )
 
 type replacer struct {
@@ -20,6 +17,10 @@ var (
 	regKey   = regexp.MustCompile(`%K`)
 	regValue = regexp.MustCompile(`%V(json)?`)
 	replaces = []*replacer{
+		// logger 名称 需在 %n %l 之前替换
+		{name: "logger", reg: regexp.MustCompile(`%logger`), fun: func(s string, r *Record) string {
+			return r.Name
+		}},
 		// 换行
 		{name: "newline", reg: regexp.MustCompile(`%[Nn]`), fun: func(s string, r *Record) string {
 			return "\n"
@@ -43,27 +44,27 @@ var (
 		}},
 		// 所在文件
 		{name: "file", reg: regexp.MustCompile(`(%F(ILE|ile)?)|(%file)`), fun: func(s string, r *Record) string {
-			f := caller.GetFrame(r.PC)
+			f := r.Frame()
 			return f.File
 		}},
 		// 所在行号
 		{name: "line", reg: regexp.MustCompile(`%L`), fun: func(s string, r *Record) string {
-			f := caller.GetFrame(r.PC)
+			f := r.Frame()
 			return strconv.Itoa(f.Line)
 		}},
 		// 函数名 %Fun %FUN
 		{name: "function", reg: regexp.MustCompile(`%fun`), fun: func(s string, r *Record) string {
-			f := caller.GetFrame(r.PC)
+			f := r.Frame()
 			return f.Fun
 		}},
 		// 包名 %P / %Pkg
 		{name: "package", reg: regexp.MustCompile(`%P([Kk][Gg])?`), fun: func(s string, r *Record) string {
-			f := caller.GetFrame(r.PC)
+			f := r.Frame()
 			return f.Pkg
 		}},
 		// 路径
 		{name: "path", reg: regexp.MustCompile(`%path`), fun: func(s string, r *Record) string {
-			f := caller.GetFrame(r.PC)
+			f := r.Frame()
 			return f.Path
 		}},
 		// 日期格式 参数不为空
@@ -105,14 +106,7 @@ var (
 		}},
 		// Attr all
 		{name: "attr-all", reg: regexp.MustCompile(`%X`), fun: func(s string, r *Record) string {
-			var sb strings.Builder
-			for i := 0; i < len(r.Attr); i = i + 2 {
-				if i > 0 {
-					sb.WriteString(" ")
-				}
-				sb.WriteString(fmt.Sprintf("%v=%v", r.Attr[i], r.Attr[i+1]))
-			}
-			return sb.String()
+			return Group(r.Attr).String()
 		}},
 		// Attr range
 		{name: "attr-range", reg: regexp.MustCompile(`%Attr{(.+?)}{(.*?)}{(.*?)}{(.*?)}`), fun: func(s string, r *Record) string {
@@ -137,8 +131,7 @@ var (
 					})
 					pair = regValue.ReplaceAllStringFunc(pair, func(s string) string {
 						if strings.Contains(s, "json") {
-							b, _ := json.Marshal(r.Attr[i+1])
-							return fmt.Sprintf("%s", b)
+							return string(jsonValue(r.Attr[i+1]))
 						}
 						return fmt.Sprintf("%v", r.Attr[i+1])
 					})
@@ -6,7 +6,7 @@ import (
 	"testing"
 	"time"
 
-	"code.gopub.tech/logs/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/caller"
 )
 
 func Test_formatRecord(t *testing.T) {
@@ -45,8 +45,8 @@ func Test_formatRecord(t *testing.T) {
 		{name: "File", args: args{format: "%File", r: record}, want: "handler_format_test.go"},
 		{name: "File-lower", args: args{format: "%file", r: record}, want: "handler_format_test.go"},
 		{name: "File-upper", args: args{format: "%FILE", r: record}, want: "handler_format_test.go"},
-		{name: "FileLine/PkgPath", args: args{format: "%F:%L %P %path", r: record}, want: "handler_format_test.go:14 code.gopub.tech/logs " + path},
-		{name: "FileLine/PkglongPath", args: args{format: "%F:%L %PkG %path", r: record}, want: "handler_format_test.go:14 code.gopub.tech/logs " + path},
+		{name: "FileLine/PkgPath", args: args{format: "%F:%L %P %path", r: record}, want: "handler_format_test.go:14 code.gopub.tech/logs/v2 " + path},
+		{name: "FileLine/PkglongPath", args: args{format: "%F:%L %PkG %path", r: record}, want: "handler_format_test.go:14 code.gopub.tech/logs/v2 " + path},
 		{name: "Fun", args: args{format: "%fun", r: record}, want: `Test_formatRecord`},
 		{name: "AttrKey", args: args{format: "%X(Str) %X(Bool)", r: record}, want: `Value true`},
 		{name: "AttrAll", args: args{format: "%X", r: record}, want: `Str=Value Bool=true`},
@@ -112,7 +112,7 @@ func Test_formatRecordToJSON(t *testing.T) {
 		{
 			name: "case2",
 			args: args{r: r1},
-			want: fmt.Sprintf(`{"ts":%d,"time":"%s","level":"INFO","pkg":"code.gopub.tech/logs/pkg/caller","fun":"PC","path":"%s","file":"pc.go","line":10,"key":"value","num":42,"msg":"Hello, World!"}`+"\n",
+			want: fmt.Sprintf(`{"ts":%d,"time":"%s","level":"INFO","pkg":"code.gopub.tech/logs/v2/pkg/caller","fun":"PC","path":"%s","file":"pc.go","line":10,"key":"value","num":42,"msg":"Hello, World!"}`+"\n",
 				r1.Time.UnixNano(), r1.Time.Format(timeFormatOnJSON), dir),
 		},
 	}
@@ -157,7 +157,7 @@ func Test_formatRecordToString(t *testing.T) {
 		{
 			name: "case2-with-pc-file",
 			args: args{r: r1},
-			want: fmt.Sprintf("%s INFO  code.gopub.tech/logs/pkg/caller.PC %s/pc.go:10 key=value num=42 Hello, World!\n", r1.Time.Format(timeFormatOnText), dir),
+			want: fmt.Sprintf("%s INFO  code.gopub.tech/logs/v2/pkg/caller.PC %s/pc.go:10 key=value num=42 Hello, World!\n", r1.Time.Format(timeFormatOnText), dir),
 		},
 	}
 	for _, tt := range tests {
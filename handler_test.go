@@ -2,14 +2,17 @@ package logs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
-	"code.gopub.tech/logs/pkg/caller"
-	"code.gopub.tech/logs/pkg/trie"
+	"code.gopub.tech/logs/v2/pkg/arg"
+	"code.gopub.tech/logs/v2/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/trie"
 )
 
 var (
@@ -114,13 +117,13 @@ func Test_toJSON(t *testing.T) {
 		{
 			name: "case1",
 			args: args{r: r0},
-			want: fmt.Sprintf(`{"ts":%d,"time":"%s","level":"INFO","pkg":"","fun":"","path":"","file":"","line":0,"key":"value","msg":"Hello, World!"}`+"\n",
+			want: fmt.Sprintf(`{"ts":%d,"time":"%s","level":"INFO","key":"value","msg":"Hello, World!"}`+"\n",
 				r0.Time.UnixNano(), r0.Time.Format(timeFormatOnJSON)),
 		},
 		{
 			name: "case2",
 			args: args{r: r1},
-			want: fmt.Sprintf(`{"ts":%d,"time":"%s","level":"INFO","pkg":"code.gopub.tech/logs/pkg/caller","fun":"PC","path":"%s","file":"pc.go","line":10,"key":"value","num":42,"msg":"Hello, World!"}`+"\n",
+			want: fmt.Sprintf(`{"ts":%d,"time":"%s","level":"INFO","pkg":"code.gopub.tech/logs/v2/pkg/caller","fun":"PC","path":"%s","file":"pc.go","line":10,"key":"value","num":42,"msg":"Hello, World!"}`+"\n",
 				r1.Time.UnixNano(), r1.Time.Format(timeFormatOnJSON), dir),
 		},
 	}
@@ -133,6 +136,26 @@ func Test_toJSON(t *testing.T) {
 	}
 }
 
+func Test_toJSON_badJSON(t *testing.T) {
+	bad := arg.Lazy(func() any { return func() {} })
+	for _, format := range []Option{WithJSON(), WithFormat(`{%Attr{%Q(%K):%Vjson}{}{,}{}}%n`)} {
+		r := r0
+		r.Attr = []any{"lazy", bad, "group", Group{"ch", make(chan int), "ok", 1}, "key", "value"}
+		var buf strings.Builder
+		NewHandler(WithWriter(&buf), format).Output(r)
+		var m map[string]any
+		if err := json.Unmarshal([]byte(buf.String()), &m); err != nil {
+			t.Fatalf("invalid json: %v\n%s", err, buf.String())
+		}
+		if s, _ := m["lazy"].(string); !strings.HasPrefix(s, "!(BADJSON: ") || m["key"] != "value" {
+			t.Errorf("unexpected output: %s", buf.String())
+		}
+		if s, _ := m["group"].(string); !strings.HasPrefix(s, "!(BADJSON: ") {
+			t.Errorf("the group with a bad value should be replaced: %s", buf.String())
+		}
+	}
+}
+
 func Test_toString(t *testing.T) {
 	type args struct {
 		r Record
@@ -145,12 +168,12 @@ func Test_toString(t *testing.T) {
 		{
 			name: "case1-unknown-file",
 			args: args{r: r0},
-			want: fmt.Sprintf("%s INFO  ?.? ?/???:0 key=value Hello, World!\n", r0.Time.Format(timeFormatOnText)),
+			want: fmt.Sprintf("%s INFO  key=value Hello, World!\n", r0.Time.Format(timeFormatOnText)),
 		},
 		{
 			name: "case2-with-pc-file",
 			args: args{r: r1},
-			want: fmt.Sprintf("%s INFO  code.gopub.tech/logs/pkg/caller.PC %s/pc.go:10 key=value num=42 Hello, World!\n", r1.Time.Format(timeFormatOnText), dir),
+			want: fmt.Sprintf("%s INFO  code.gopub.tech/logs/v2/pkg/caller.PC %s/pc.go:10 key=value num=42 Hello, World!\n", r1.Time.Format(timeFormatOnText), dir),
 		},
 	}
 	for _, tt := range tests {
@@ -189,10 +212,10 @@ func Test_handler_Enable(t *testing.T) {
 		{name: "levelProvider/pkgName", fields: fields{levelConfig: trie.NewTree(LevelInfo)},
 			args: args{level: LevelInfo, pc: caller.PC(0)}, want: true},
 		{name: "levelProvider/pkgName/logs", fields: fields{
-			levelConfig: trie.NewTree(LevelInfo).Insert("code.gopub.tech/logs", LevelWarn)},
+			levelConfig: trie.NewTree(LevelInfo).Insert("code.gopub.tech/logs/v2", LevelWarn)},
 			args: args{level: LevelInfo, pc: caller.PC(0)}, want: false},
 		{name: "levelProvider/pkgName/logs/warn", fields: fields{
-			levelConfig: trie.NewTree(LevelInfo).Insert("code.gopub.tech/logs", LevelWarn)},
+			levelConfig: trie.NewTree(LevelInfo).Insert("code.gopub.tech/logs/v2", LevelWarn)},
 			args: args{level: LevelWarn, pc: caller.PC(0)}, want: true},
 	}
 	for _, tt := range tests {
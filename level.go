@@ -3,6 +3,8 @@ package logs
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
 type Level int
@@ -23,42 +25,64 @@ const (
 	LevelOFF Level = math.MaxInt // [关闭]
 )
 
+// String returns the registered name of the level, such as "INFO".
+// if the level is not registered, returns the name of nearest lower level with offset, such as "INFO+1".
+//
+// 返回级别名称, 如 "INFO". 如果级别未注册, 返回最近的较低级别名称及偏移, 如 "INFO+1". 参见 `RegisterLevel`.
 func (l Level) String() string {
-	switch l {
-	case LevelTrace:
-		return "TRACE"
-	case LevelDebug:
-		return "DEBUG"
-	case LevelInfo:
-		return "INFO"
-	case LevelNotice:
-		return "NOTICE"
-	case LevelWarn:
-		return "WARN"
-	case LevelError:
-		return "ERROR"
-	case LevelPanic:
-		return "PANIC"
-	case LevelFatal:
-		return "FATAL"
-	default:
-		switch {
-		case l > LevelFatal:
-			return fmt.Sprintf("FATAL%+d", l-LevelFatal)
-		case l > LevelPanic:
-			return fmt.Sprintf("PANIC%+d", l-LevelPanic)
-		case l > LevelError:
-			return fmt.Sprintf("ERROR%+d", l-LevelError)
-		case l > LevelWarn:
-			return fmt.Sprintf("WARN%+d", l-LevelWarn)
-		case l > LevelNotice:
-			return fmt.Sprintf("NOTICE%+d", l-LevelNotice)
-		case l > LevelInfo:
-			return fmt.Sprintf("INFO%+d", l-LevelInfo)
-		case l > LevelDebug:
-			return fmt.Sprintf("DEBUG%+d", l-LevelDebug)
-		default:
-			return fmt.Sprintf("TRACE%+d", l-LevelTrace)
+	info := lookupLevel(l)
+	if info.level == l {
+		return info.name
+	}
+	return fmt.Sprintf("%s%+d", info.name, l-info.level)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// ParseLevel parse a level from string, the string can be a registered level name (case insensitive),
+// a level name with offset such as "WARN+1", or a number such as "-10".
+//
+// 将字符串解析为日志级别. 支持已注册的级别名称(不区分大小写), 带偏移的名称(如 "WARN+1")以及数字(如 "-10").
+func ParseLevel(s string) (Level, error) {
+	s = strings.TrimSpace(s)
+	if i, err := strconv.Atoi(s); err == nil {
+		return Level(i), nil
+	}
+	if l, ok := levelByName(s); ok {
+		return l, nil
+	}
+	name, offset := s, 0
+	if i := strings.IndexAny(s, "+-"); i > 0 {
+		n, err := strconv.Atoi(s[i:])
+		if err != nil {
+			return 0, fmt.Errorf("logs: invalid level %q: %w", s, err)
 		}
+		name, offset = s[:i], n
+	}
+	switch strings.ToUpper(name) {
+	case "ALL":
+		return LevelALL, nil
+	case "OFF":
+		return LevelOFF, nil
+	case "WARNING":
+		name = "WARN"
+	}
+	l, ok := levelByName(name)
+	if !ok {
+		return 0, fmt.Errorf("logs: unknown level %q", s)
 	}
+	return l + Level(offset), nil
 }
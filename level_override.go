@@ -0,0 +1,122 @@
+package logs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type levelOverrideKey struct{}
+
+// WithLevelOverride returns a copy of ctx which carries the level.
+// Handlers use this level instead of the configured level to decide
+// whether a log with this ctx should be output.
+//
+// 在 ctx 上设置日志级别. 处理器判断使用该 ctx 的日志是否输出时, 优先使用该级别而非配置的级别.
+// 常用于为单个请求开启 Debug 日志.
+func WithLevelOverride(ctx context.Context, level Level) context.Context {
+	return context.WithValue(ctx, levelOverrideKey{}, level)
+}
+
+// LevelOverride returns the level set by `WithLevelOverride`.
+//
+// 获取 `WithLevelOverride` 设置的日志级别.
+func LevelOverride(ctx context.Context) (Level, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	level, ok := ctx.Value(levelOverrideKey{}).(Level)
+	return level, ok
+}
+
+const (
+	LevelOverrideHeader     = "X-Log-Level"      // 请求头: 日志级别
+	LevelOverrideSignHeader = "X-Log-Level-Sign" // 请求头: 日志级别签名
+	LevelOverrideQuery      = "log_level"        // 查询参数: 日志级别
+	LevelOverrideSignQuery  = "log_level_sign"   // 查询参数: 日志级别签名
+)
+
+// SignLevel returns the signature of the level string which is valid until expires, the format is
+// `<unix seconds of expires>.<hex of HMAC-SHA256>`, the HMAC covers both the level and the expiry,
+// so a leaked signature can not be used after it expires.
+//
+//	sign := logs.SignLevel(secret, "debug", time.Now().Add(time.Hour))
+//
+// 计算日志级别字符串的签名, 在 expires 之前有效, 用于 `LevelOverrideMiddleware`.
+// 格式为 `<过期时间的 unix 秒数>.<HMAC-SHA256 的十六进制形式>`, HMAC 同时覆盖级别及过期时间,
+// 因此泄露的签名过期后无法再使用.
+func SignLevel(secret []byte, level string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + levelMAC(secret, level, exp)
+}
+
+func levelMAC(secret []byte, level, exp string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(level + "\n" + exp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyLevel reports whether the sign is a valid and unexpired signature of the level.
+//
+// 返回 sign 是否为该级别的有效且未过期的签名.
+func verifyLevel(secret []byte, level, sign string, now time.Time) bool {
+	exp, mac, ok := strings.Cut(sign, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() >= expires {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(levelMAC(secret, level, exp)))
+}
+
+// LevelOverrideMiddleware returns a http middleware which calls `WithLevelOverride` on the request ctx
+// if the request carries a level and a valid signature, in the header or in the query:
+//
+//	X-Log-Level: debug
+//	X-Log-Level-Sign: SignLevel(secret, "debug", expires)
+//	or
+//	?log_level=debug&log_level_sign=SignLevel(secret, "debug", expires)
+//
+// If the secret is empty, the level is always ignored, and so is an expired signature.
+//
+// 返回一个 http 中间件, 如果请求头或查询参数中带有日志级别及正确的签名, 就在请求的 ctx 上设置该级别.
+// 如果 secret 为空, 则总是忽略请求中的级别, 签名过期时同样忽略.
+func LevelOverrideMiddleware(secret []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if level, ok := levelFromRequest(r, secret); ok {
+				r = r.WithContext(WithLevelOverride(r.Context(), level))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// levelFromRequest get the signed level from the request header or query.
+//
+// 从请求头或查询参数中获取已签名的日志级别.
+func levelFromRequest(r *http.Request, secret []byte) (Level, bool) {
+	if len(secret) == 0 {
+		return 0, false
+	}
+	s, sign := r.Header.Get(LevelOverrideHeader), r.Header.Get(LevelOverrideSignHeader)
+	if s == "" {
+		q := r.URL.Query()
+		s, sign = q.Get(LevelOverrideQuery), q.Get(LevelOverrideSignQuery)
+	}
+	if s == "" || !verifyLevel(secret, s, sign, time.Now()) {
+		return 0, false
+	}
+	level, err := ParseLevel(s)
+	if err != nil {
+		return 0, false
+	}
+	return level, true
+}
@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithLevelOverride(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(WithWriter(&buf))
+	logger := NewLogger(h)
+	debugCtx := WithLevelOverride(context.Background(), LevelDebug)
+
+	if logger.EnableContext(context.Background(), LevelDebug) {
+		t.Errorf("debug should not enabled without override")
+	}
+	if !logger.EnableContext(debugCtx, LevelDebug) {
+		t.Errorf("debug should enabled with override")
+	}
+	logger.Debug(context.Background(), "not output")
+	logger.Debug(debugCtx, "output")
+	if got := buf.String(); strings.Contains(got, "not output") || !strings.Contains(got, "output") {
+		t.Errorf("unexpected output: %s", got)
+	}
+
+	buf.Reset()
+	NewLogger(CombineHandlers(h, NewHandler(WithWriter(&buf), WithoutLevelOverride()))).Debug(debugCtx, "once")
+	if got := strings.Count(buf.String(), "once"); got != 1 {
+		t.Errorf("WithoutLevelOverride: output %d times, want 1", got)
+	}
+}
+
+func TestLevelOverrideMiddleware(t *testing.T) {
+	secret := []byte("secret")
+	sign := SignLevel(secret, "debug", time.Now().Add(time.Hour))
+	tests := []struct {
+		name   string
+		secret []byte
+		setup  func(r *http.Request)
+		want   Level
+		wantOK bool
+	}{
+		{name: "none", secret: secret, setup: func(r *http.Request) {}},
+		{name: "header", secret: secret, setup: func(r *http.Request) {
+			r.Header.Set(LevelOverrideHeader, "debug")
+			r.Header.Set(LevelOverrideSignHeader, sign)
+		}, want: LevelDebug, wantOK: true},
+		{name: "header-bad-sign", secret: secret, setup: func(r *http.Request) {
+			r.Header.Set(LevelOverrideHeader, "trace")
+			r.Header.Set(LevelOverrideSignHeader, sign)
+		}},
+		{name: "query", secret: secret, setup: func(r *http.Request) {
+			q := r.URL.Query()
+			q.Set(LevelOverrideQuery, "debug")
+			q.Set(LevelOverrideSignQuery, sign)
+			r.URL.RawQuery = q.Encode()
+		}, want: LevelDebug, wantOK: true},
+		{name: "empty-secret", setup: func(r *http.Request) {
+			r.Header.Set(LevelOverrideHeader, "debug")
+			r.Header.Set(LevelOverrideSignHeader, SignLevel(nil, "debug", time.Now().Add(time.Hour)))
+		}},
+		{name: "expired", secret: secret, setup: func(r *http.Request) {
+			r.Header.Set(LevelOverrideHeader, "debug")
+			r.Header.Set(LevelOverrideSignHeader, SignLevel(secret, "debug", time.Now().Add(-time.Second)))
+		}},
+		{name: "forged-expiry", secret: secret, setup: func(r *http.Request) {
+			expired := SignLevel(secret, "debug", time.Now().Add(-time.Second))
+			_, mac, _ := strings.Cut(expired, ".")
+			r.Header.Set(LevelOverrideHeader, "debug")
+			r.Header.Set(LevelOverrideSignHeader, strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)+"."+mac)
+		}},
+		{name: "no-expiry", secret: secret, setup: func(r *http.Request) {
+			_, mac, _ := strings.Cut(sign, ".")
+			r.Header.Set(LevelOverrideHeader, "debug")
+			r.Header.Set(LevelOverrideSignHeader, mac)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Level
+			var gotOK bool
+			h := LevelOverrideMiddleware(tt.secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, gotOK = LevelOverride(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.setup(r)
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want || gotOK != tt.wantOK {
+				t.Errorf("LevelOverride() = %v, %v, want %v, %v", got, gotOK, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fatih/color"
+)
+
+// levelInfo is a registered level.
+//
+// 已注册的日志级别.
+type levelInfo struct {
+	level     Level
+	name      string       // display name 显示名称
+	color     *color.Color // nil means use the color of lower level 为空则使用较低级别的颜色
+	slogLevel int          // the slog.Level of this level 对应的 slog.Level
+	hasSlog   bool
+}
+
+// LevelOption is the option of RegisterLevel.
+//
+// 注册日志级别的选项.
+type LevelOption func(*levelInfo)
+
+// LevelColor set the output color of the level.
+// if not set or c is nil, the color of nearest lower level is used.
+//
+// 设置级别的输出颜色. 未设置或 c 为 nil 时使用最近的较低级别的颜色.
+func LevelColor(c *color.Color) LevelOption {
+	return func(info *levelInfo) {
+		if c != nil {
+			c.EnableColor() // if the handler's colorMode=force we need enable color
+		}
+		info.color = c
+	}
+}
+
+var (
+	levelsMu sync.Mutex   // 注册时加锁
+	levels   atomic.Value // []*levelInfo sorted by level 按级别排序
+)
+
+func init() {
+	levels.Store([]*levelInfo{
+		{level: LevelTrace, name: "TRACE", color: traceColor},
+		{level: LevelDebug, name: "DEBUG", color: debugColor},
+		{level: LevelInfo, name: "INFO", color: infoColor},
+		{level: LevelNotice, name: "NOTICE", color: noticeColor},
+		{level: LevelWarn, name: "WARN", color: warnColor},
+		{level: LevelError, name: "ERROR", color: errorColor},
+		{level: LevelPanic, name: "PANIC", color: panicColor},
+		{level: LevelFatal, name: "FATAL", color: fatalColor},
+	})
+}
+
+// RegisterLevel register a named level, so the level is output as the name
+// and can be parsed by ParseLevel. register a existing level would replace it.
+// It panics if the name is already used by another level.
+//
+//	const LevelAudit = logs.LevelNotice + 5
+//	logs.RegisterLevel(LevelAudit, "AUDIT", logs.LevelColor(color.New(color.FgMagenta)))
+//	LevelAudit.Log(ctx, "user %v login", uid)
+//
+// 注册一个命名的日志级别, 输出日志时会显示该名称, 也可以被 ParseLevel 解析.
+// 重复注册相同的级别会覆盖之前的注册. 如果名称已被其他级别使用会 panic.
+func RegisterLevel(level Level, name string, opts ...LevelOption) {
+	info := &levelInfo{level: level, name: name}
+	for _, opt := range opts {
+		opt(info)
+	}
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	old := registeredLevels()
+	infos := make([]*levelInfo, 0, len(old)+1)
+	for _, i := range old {
+		if i.level == level {
+			continue // replaced
+		}
+		if strings.EqualFold(i.name, name) {
+			panic(fmt.Sprintf("logs: level name %q is already registered as %d", name, i.level))
+		}
+		infos = append(infos, i)
+	}
+	infos = append(infos, info)
+	sort.Slice(infos, func(i, j int) bool { return infos[i].level < infos[j].level })
+	levels.Store(infos)
+}
+
+func registeredLevels() []*levelInfo {
+	return levels.Load().([]*levelInfo)
+}
+
+// lookupLevel returns the registered level which is the nearest lower or equal to the level.
+// if the level is lower than all registered levels, the lowest one is returned.
+//
+// 查找不高于指定级别的最近的已注册级别. 如果指定级别比所有已注册级别都低, 返回最低的级别.
+func lookupLevel(level Level) *levelInfo {
+	infos := registeredLevels()
+	i := sort.Search(len(infos), func(i int) bool { return infos[i].level > level }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return infos[i]
+}
+
+// levelByName find the registered level by name, case insensitive.
+//
+// 按名称查找已注册的级别, 不区分大小写.
+func levelByName(name string) (Level, bool) {
+	for _, info := range registeredLevels() {
+		if strings.EqualFold(info.name, name) {
+			return info.level, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,91 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestRegisterLevel(t *testing.T) {
+	const (
+		levelAudit    = LevelNotice + 5
+		levelSecurity = LevelNotice + 7
+	)
+	RegisterLevel(levelAudit, "AUDIT", LevelColor(color.New(color.FgMagenta)))
+	RegisterLevel(levelSecurity, "SECURITY", LevelColor(nil)) // nil 与未设置相同
+	defer unregisterLevel(levelAudit, levelSecurity)
+
+	for _, tt := range []struct {
+		l    Level
+		want string
+	}{
+		{levelAudit, "AUDIT"},
+		{levelAudit + 1, "AUDIT+1"},
+		{levelSecurity, "SECURITY"},
+		{levelSecurity + 1, "SECURITY+1"},
+		{LevelNotice + 1, "NOTICE+1"},
+		{LevelWarn, "WARN"},
+	} {
+		if got := tt.l.String(); got != tt.want {
+			t.Errorf("Level(%d).String() = %v, want %v", int(tt.l), got, tt.want)
+		}
+		if got, err := ParseLevel(strings.ToLower(tt.want)); err != nil || got != tt.l {
+			t.Errorf("ParseLevel(%v) = %v, %v, want %v", tt.want, got, err, int(tt.l))
+		}
+	}
+
+	if got, want := defaultColor(levelAudit, "Hello"), "\033[35mHello\033[0m"; got != want {
+		t.Errorf("defaultColor(AUDIT) = %q, want %q", got, want)
+	}
+	if got, want := defaultColor(levelSecurity, "Hello"), "\033[35mHello\033[0m"; got != want {
+		t.Errorf("defaultColor(SECURITY) = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf), WithJSON()))
+	levelAudit.LogTo(ctx, logger, "audit log")
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil || m["level"] != "AUDIT" {
+		t.Errorf("json output = %s, err=%v", buf.String(), err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("register duplicate name should panic")
+		}
+	}()
+	RegisterLevel(levelAudit+1, "audit")
+}
+
+func TestLevel_MarshalText(t *testing.T) {
+	var s struct{ Level Level }
+	if err := json.Unmarshal([]byte(`{"Level":"warn+1"}`), &s); err != nil || s.Level != LevelWarn+1 {
+		t.Errorf("UnmarshalText got %v, %v", s.Level, err)
+	}
+	if b, err := json.Marshal(s); err != nil || string(b) != `{"Level":"WARN+1"}` {
+		t.Errorf("MarshalText got %s, %v", b, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Level":"unknown"}`), &s); err == nil {
+		t.Errorf("UnmarshalText should fail")
+	}
+}
+
+// unregisterLevel remove the registered levels, used to restore after test.
+func unregisterLevel(remove ...Level) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	var infos []*levelInfo
+next:
+	for _, info := range registeredLevels() {
+		for _, l := range remove {
+			if info.level == l {
+				continue next
+			}
+		}
+		infos = append(infos, info)
+	}
+	levels.Store(infos)
+}
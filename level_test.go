@@ -35,3 +35,38 @@ func TestLevel_String(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Level
+		wantErr bool
+	}{
+		{"trace", LevelTrace, false},
+		{"DEBUG", LevelDebug, false},
+		{" Info ", LevelInfo, false},
+		{"notice", LevelNotice, false},
+		{"warning", LevelWarn, false},
+		{"WARN+1", LevelWarn + 1, false},
+		{"error-2", LevelError - 2, false},
+		{"panic", LevelPanic, false},
+		{"fatal", LevelFatal, false},
+		{"all", LevelALL, false},
+		{"OFF", LevelOFF, false},
+		{"-10", LevelDebug, false},
+		{"unknown", 0, true},
+		{"info+x", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseLevel(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,46 @@ package logs
 
 import (
 	"context"
+	"os"
+	"runtime"
 	"time"
 
-	"code.gopub.tech/logs/pkg/caller"
-	"code.gopub.tech/logs/pkg/kv"
+	"code.gopub.tech/logs/v2/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/kv"
 )
 
 type Logger interface {
 	With(key, value any) Logger
+	// WithKV is like With, but accepts multi key-value pairs or Fields.
+	//
+	// 与 With 类似, 但可以传入多个键值对或 Field.
+	WithKV(kvs ...any) Logger
+	// WithError is like With, the err is added with key "error", see `Err`.
+	//
+	// 与 With 类似, err 以 "error" 为键添加, 参见 `Err`.
+	WithError(err error) Logger
+	// Named returns a Logger whose name is the name of this Logger joined with the name by a dot,
+	// such as logger.Named("db").Named("pool") is named "db.pool".
+	// The name is used to search level in the LevelProvider, and is shown in the output.
+	//
+	// 返回一个命名的 Logger, 名称以点号连接到当前 Logger 的名称之后,
+	// 如 logger.Named("db").Named("pool") 的名称为 "db.pool".
+	// 该名称用于在 LevelProvider 中查找日志级别, 并会在日志中输出.
+	Named(name string) Logger
+	Name() string
+	// AddCallerSkip returns a Logger which skips n more frames when getting the log position,
+	// useful for the wrapper functions.
+	//
+	// 返回一个获取打印日志位置时额外跳过 n 层调用栈的 Logger, 可用于封装的日志函数.
+	AddCallerSkip(n int) Logger
+	// WithGroup returns a Logger that starts a group, the attrs added after (by With or the KV methods)
+	// are in the group, such as `http.method=GET` in text and `{"http":{"method":"GET"}}` in json.
+	// like slog, an empty name is ignored and an empty group is not output.
+	//
+	// 返回一个开启了分组的 Logger, 之后添加的键值对(通过 With 或 KV 方法)都在该分组内,
+	// 如文本格式的 `http.method=GET` 及 json 格式的 `{"http":{"method":"GET"}}`.
+	// 与 slog 一致, 名称为空时忽略, 空的分组不会输出.
+	WithGroup(name string) Logger
 	Trace(ctx context.Context, format string, args ...any)
 	Debug(ctx context.Context, format string, args ...any)
 	Info(ctx context.Context, format string, args ...any)
@@ -21,22 +53,227 @@ type Logger interface {
 	// Log 打印日志接口
 	// callDepth: 0=caller position
 	Log(ctx context.Context, callDepth int, level Level, format string, args ...any)
+	// structured logging: msg is not a format, kvs are key-value pairs or Fields.
+	// 结构化日志: msg 不是格式化字符串, kvs 为键值对或 Field.
+	TraceKV(ctx context.Context, msg string, kvs ...any)
+	DebugKV(ctx context.Context, msg string, kvs ...any)
+	InfoKV(ctx context.Context, msg string, kvs ...any)
+	NoticeKV(ctx context.Context, msg string, kvs ...any)
+	WarnKV(ctx context.Context, msg string, kvs ...any)
+	ErrorKV(ctx context.Context, msg string, kvs ...any)
+	PanicKV(ctx context.Context, msg string, kvs ...any)
+	FatalKV(ctx context.Context, msg string, kvs ...any)
+	LogKV(ctx context.Context, callDepth int, level Level, msg string, kvs ...any)
+	// typed structured logging: like the KV methods, but the Fields are not boxed into any.
+	// 带类型的结构化日志: 与 KV 方法类似, 但 Field 不会被装箱为 any.
+	TraceFields(ctx context.Context, msg string, fs ...Field)
+	DebugFields(ctx context.Context, msg string, fs ...Field)
+	InfoFields(ctx context.Context, msg string, fs ...Field)
+	NoticeFields(ctx context.Context, msg string, fs ...Field)
+	WarnFields(ctx context.Context, msg string, fs ...Field)
+	ErrorFields(ctx context.Context, msg string, fs ...Field)
+	PanicFields(ctx context.Context, msg string, fs ...Field)
+	FatalFields(ctx context.Context, msg string, fs ...Field)
+	LogFields(ctx context.Context, callDepth int, level Level, msg string, fs ...Field)
 	Enable(level Level) bool
 	EnableDepth(level Level, callDepth int) bool
+	// EnableContext is like Enable, but the ctx is used to decide, see `WithLevelOverride`.
+	//
+	// 与 Enable 类似, 但会使用 ctx 判断是否启用, 参见 `WithLevelOverride`.
+	EnableContext(ctx context.Context, level Level) bool
+	EnableContextDepth(ctx context.Context, level Level, callDepth int) bool
 }
 
-func NewLogger(h Handler) Logger {
-	return &logger{h: h}
+// NewLogger create a Logger which output logs to the Handler.
+//
+// 创建一个 Logger, 日志会交给 Handler 处理.
+func NewLogger(h Handler, opts ...LoggerOption) Logger {
+	l := &logger{h: h, noCaller: !needCaller(h), exitCode: 1, exitFunc: os.Exit}
+	for _, op := range opts {
+		op(l)
+	}
+	l.levelCaller = levelNeedCaller(h, l.name)
+	return l
+}
+
+// LoggerOption Logger options.
+//
+// Logger 的配置选项.
+type LoggerOption func(*logger)
+
+// WithExitCode set the exit code when output a Fatal log, default is 1.
+//
+// 设置输出 Fatal 日志后程序的退出码, 默认为 1.
+func WithExitCode(code int) LoggerOption { return func(l *logger) { l.exitCode = code } }
+
+// WithExitFunc set the function to terminate the program when output a Fatal log, default is os.Exit.
+// useful for tests.
+//
+// 设置输出 Fatal 日志后终止程序的函数, 默认为 os.Exit. 可用于测试.
+func WithExitFunc(fn func(code int)) LoggerOption { return func(l *logger) { l.exitFunc = fn } }
+
+// WithExitHook add hooks which will be called before exit when output a Fatal log,
+// such as flush or close files. see also `RegisterExitHook`.
+//
+// 添加退出前的钩子函数, 输出 Fatal 日志后终止程序前会调用, 如刷新缓冲、关闭文件等. 另请参见 `RegisterExitHook`.
+func WithExitHook(hooks ...func()) LoggerOption {
+	return func(l *logger) { l.exitHooks = append(l.exitHooks[:len(l.exitHooks):len(l.exitHooks)], hooks...) }
+}
+
+// WithCallerSkipPackages skip the frames of the packages when getting the log position,
+// so the log points at the real caller instead of the wrapper package.
+//
+// 获取打印日志位置时跳过这些包的调用栈, 使日志指向真正的调用处而不是封装日志的包.
+func WithCallerSkipPackages(pkgs ...string) LoggerOption {
+	return func(l *logger) {
+		l.skipPkgs = make(map[string]bool, len(l.skipPkgs)+len(pkgs))
+		for pkg := range l.skipPkgs {
+			l.skipPkgs[pkg] = true
+		}
+		for _, pkg := range pkgs {
+			l.skipPkgs[pkg] = true
+		}
+	}
 }
 
 type logger struct {
-	h     Handler
-	attrs []any
+	h         Handler
+	noCaller  bool // the Handler does not need the log position, see WithoutCaller
+	name      string
+	attrs     []any
+	groups    []openGroup     // the attrs added after WithGroup are in the last group
+	exitCode  int             // exit code of Fatal 退出码
+	exitFunc  func(int)       // os.Exit
+	exitHooks []func()        // call before exit 退出前调用
+	skip      int             // extra call depth   额外跳过的调用栈层数
+	skipPkgs  map[string]bool // skipped packages   跳过的包
+
+	precedence AttrPrecedence // precedence of the Logger attrs and the ctx kvs
+	seqs       []uint64       // sequence number of each pair of attrs, only for NewestWins
+
+	levelCaller bool // the Handler needs the log position to check the level 处理器判断级别时需要日志位置
+}
+
+func (l *logger) clone() *logger {
+	c := *l
+	return &c
 }
 
 func (l *logger) With(key, value any) Logger {
-	attrs := append(l.attrs, key, value)
-	return &logger{h: l.h, attrs: attrs}
+	return l.withAttrs([]any{key, value})
+}
+
+func (l *logger) WithKV(kvs ...any) Logger {
+	return l.withAttrs(attrsOf(kvs))
+}
+
+func (l *logger) WithError(err error) Logger {
+	return l.withAttrs(attrsOf([]any{Err(err)}))
+}
+
+// withAttrs add attrs to the Logger or the current group.
+//
+// 添加键值对到 Logger 或当前分组.
+func (l *logger) withAttrs(attrs []any) Logger {
+	c := l.clone()
+	var seq uint64
+	if l.precedence == NewestWins {
+		seq = kv.NextSeq()
+	}
+	if n := len(l.groups); n > 0 {
+		g := l.groups[n-1]
+		g.attrs = append(g.attrs[:len(g.attrs):len(g.attrs)], attrs...)
+		g.seq = seq
+		c.groups = append(l.groups[:n-1:n-1], g)
+	} else {
+		c.attrs = append(l.attrs[:len(l.attrs):len(l.attrs)], attrs...)
+		if l.precedence == NewestWins {
+			c.seqs = l.seqs[:len(l.seqs):len(l.seqs)]
+			for i := 0; i+1 < len(attrs); i += 2 {
+				c.seqs = append(c.seqs, seq)
+			}
+		}
+	}
+	return c
+}
+
+func (l *logger) WithGroup(name string) Logger {
+	if name == "" {
+		return l
+	}
+	c := l.clone()
+	c.groups = append(l.groups[:len(l.groups):len(l.groups)], openGroup{name: name})
+	return c
+}
+
+// collectAttrs put the attrs of the record into the groups, and returns all attrs of the Logger.
+//
+// 将日志的键值对放入分组中, 返回 Logger 上的所有键值对.
+func (l *logger) collectAttrs(attrs []any) []any {
+	for i := len(l.groups) - 1; i >= 0; i-- {
+		g := l.groups[i]
+		attrs = append(g.attrs[:len(g.attrs):len(g.attrs)], attrs...)
+		if len(attrs) > 0 { // 空的分组不输出
+			attrs = []any{g.name, Group(attrs)}
+		}
+	}
+	return concatAttrs(l.attrs, attrs)
+}
+
+// concatAttrs returns a+b, a or b is returned without copy if the other is empty.
+//
+// 返回 a+b, 其中一个为空时直接返回另一个, 无需复制.
+func concatAttrs(a, b []any) []any {
+	switch {
+	case len(b) == 0:
+		return a
+	case len(a) == 0:
+		return b
+	}
+	return append(a[:len(a):len(a)], b...)
+}
+
+func (l *logger) Named(name string) Logger {
+	if name == "" {
+		return l
+	}
+	c := l.clone()
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	c.name = name
+	c.levelCaller = levelNeedCaller(c.h, name)
+	return c
+}
+
+func (l *logger) Name() string { return l.name }
+
+func (l *logger) AddCallerSkip(n int) Logger {
+	c := l.clone()
+	c.skip += n
+	return c
+}
+
+// callerPC returns the pc of the log position, the extra depth and the skipped packages are skipped.
+// returns 0 if the Handler does not need it.
+// callDepth: 0=the caller of callerPC
+//
+// 返回打印日志位置的 pc, 会跳过额外的层数及需要跳过的包. 处理器不需要时返回 0.
+func (l *logger) callerPC(callDepth int) uintptr {
+	if l.noCaller {
+		return 0
+	}
+	if len(l.skipPkgs) == 0 {
+		return caller.PC(callDepth + l.skip + 1)
+	}
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(callDepth+l.skip+2, pcs[:])
+	for _, pc := range pcs[:n] {
+		if !l.skipPkgs[caller.GetFrame(pc).Pkg] {
+			return pc
+		}
+	}
+	return caller.PC(callDepth + l.skip + 1)
 }
 
 func (l *logger) Trace(ctx context.Context, format string, args ...any) {
@@ -64,16 +301,132 @@ func (l *logger) Fatal(ctx context.Context, format string, args ...any) {
 	l.Log(ctx, 1, LevelFatal, format, args...)
 }
 
+// Log output the log Record to the Handler.
+// After output, a Fatal log would call the exit hooks and terminate the program,
+// and a Panic log would panic with a *PanicError.
+//
+// 输出日志. 输出后, Fatal 日志会调用退出钩子并终止程序, Panic 日志会以 *PanicError 抛出 panic.
 func (l *logger) Log(ctx context.Context, callDepth int, level Level, format string, args ...any) {
-	l.h.Output(Record{
+	l.output(ctx, callDepth+1, level, format, args, nil, nil)
+}
+
+func (l *logger) TraceKV(ctx context.Context, msg string, kvs ...any) {
+	l.LogKV(ctx, 1, LevelTrace, msg, kvs...)
+}
+func (l *logger) DebugKV(ctx context.Context, msg string, kvs ...any) {
+	l.LogKV(ctx, 1, LevelDebug, msg, kvs...)
+}
+func (l *logger) InfoKV(ctx context.Context, msg string, kvs ...any) {
+	l.LogKV(ctx, 1, LevelInfo, msg, kvs...)
+}
+func (l *logger) NoticeKV(ctx context.Context, msg string, kvs ...any) {
+	l.LogKV(ctx, 1, LevelNotice, msg, kvs...)
+}
+func (l *logger) WarnKV(ctx context.Context, msg string, kvs ...any) {
+	l.LogKV(ctx, 1, LevelWarn, msg, kvs...)
+}
+func (l *logger) ErrorKV(ctx context.Context, msg string, kvs ...any) {
+	l.LogKV(ctx, 1, LevelError, msg, kvs...)
+}
+func (l *logger) PanicKV(ctx context.Context, msg string, kvs ...any) {
+	l.LogKV(ctx, 1, LevelPanic, msg, kvs...)
+}
+func (l *logger) FatalKV(ctx context.Context, msg string, kvs ...any) {
+	l.LogKV(ctx, 1, LevelFatal, msg, kvs...)
+}
+
+// LogKV output a structured log, the kvs are appended after the attrs of With.
+//
+// 输出结构化日志, kvs 会追加在 With 添加的键值对之后.
+func (l *logger) LogKV(ctx context.Context, callDepth int, level Level, msg string, kvs ...any) {
+	l.output(ctx, callDepth+1, level, escapeFormat(msg), nil, kvs, nil)
+}
+
+func (l *logger) TraceFields(ctx context.Context, msg string, fs ...Field) {
+	l.LogFields(ctx, 1, LevelTrace, msg, fs...)
+}
+func (l *logger) DebugFields(ctx context.Context, msg string, fs ...Field) {
+	l.LogFields(ctx, 1, LevelDebug, msg, fs...)
+}
+func (l *logger) InfoFields(ctx context.Context, msg string, fs ...Field) {
+	l.LogFields(ctx, 1, LevelInfo, msg, fs...)
+}
+func (l *logger) NoticeFields(ctx context.Context, msg string, fs ...Field) {
+	l.LogFields(ctx, 1, LevelNotice, msg, fs...)
+}
+func (l *logger) WarnFields(ctx context.Context, msg string, fs ...Field) {
+	l.LogFields(ctx, 1, LevelWarn, msg, fs...)
+}
+func (l *logger) ErrorFields(ctx context.Context, msg string, fs ...Field) {
+	l.LogFields(ctx, 1, LevelError, msg, fs...)
+}
+func (l *logger) PanicFields(ctx context.Context, msg string, fs ...Field) {
+	l.LogFields(ctx, 1, LevelPanic, msg, fs...)
+}
+func (l *logger) FatalFields(ctx context.Context, msg string, fs ...Field) {
+	l.LogFields(ctx, 1, LevelFatal, msg, fs...)
+}
+
+// LogFields is like LogKV, but the Fields are kept as []Field until the log is enabled.
+//
+// 与 LogKV 类似, 但 Field 保持为 []Field, 直到确认日志启用后才转换.
+func (l *logger) LogFields(ctx context.Context, callDepth int, level Level, msg string, fs ...Field) {
+	l.output(ctx, callDepth+1, level, escapeFormat(msg), nil, nil, fs)
+}
+
+// output check whether the log is enabled before building the Record,
+// but the Panic and Fatal logs always panic or exit even if it is not output.
+// the log position and the behaviour may be given by the callSite on the ctx, see SlogHandler.
+//
+// 构建日志记录前先判断是否启用, 但 Panic 及 Fatal 日志即使不输出也总会抛出 panic 或退出程序.
+// 日志位置及行为可以由 ctx 上的 callSite 指定, 参见 SlogHandler.
+func (l *logger) output(ctx context.Context, callDepth int, level Level, format string, args, kvs []any, fs []Field) {
+	site, ok := callSiteOf(ctx)
+	if !ok {
+		site.pc = l.levelPC(callDepth + 1)
+	}
+	enabled := level < LevelPanic
+	if enabled && !site.levelChecked && !enableContext(l.h, ctx, l.name, level, site.pc) {
+		return
+	}
+	if !ok && !l.levelCaller { // 判断级别后再获取调用位置, 未启用的日志无需获取
+		site.pc = l.callerPC(callDepth + 1)
+	}
+	r := Record{
 		Ctx:    ctx,
 		Time:   time.Now(),
+		Name:   l.name,
 		Level:  level,
-		PC:     caller.PC(callDepth + 1),
+		PC:     site.pc,
 		Format: format,
 		Args:   args,
-		Attr:   kv.Uniq(append(l.attrs, kv.Get(ctx)...)),
-	})
+		Attr:   l.mergeAttrs(ctx, callAttrs(kvs, fs)),
+	}
+	if !site.time.IsZero() {
+		r.Time = site.time
+	}
+	if site.noSource {
+		r.PC = 0
+	}
+	switch {
+	case site.levelChecked:
+		outputForced(l.h, r)
+	case enabled:
+		outputChecked(l.h, r)
+	default:
+		l.h.Output(r)
+	}
+	if site.noExit {
+		return
+	}
+	switch {
+	case level >= LevelFatal:
+		runExitHooks(l.exitHooks)
+		l.exitFunc(l.exitCode)
+	case level >= LevelPanic:
+		r.Attr = kv.Uniq(r.Attr)
+		panic(&PanicError{Record: r})
+	}
 }
 
 func (l *logger) Enable(level Level) bool {
@@ -81,5 +434,55 @@ func (l *logger) Enable(level Level) bool {
 }
 
 func (l *logger) EnableDepth(level Level, callDepth int) bool {
-	return l.h.Enable(level, caller.PC(callDepth+1))
+	return enableContext(l.h, context.Background(), l.name, level, l.levelPC(callDepth+1))
+}
+
+func (l *logger) EnableContext(ctx context.Context, level Level) bool {
+	return l.EnableContextDepth(ctx, level, 1)
+}
+
+func (l *logger) EnableContextDepth(ctx context.Context, level Level, callDepth int) bool {
+	return enableContext(l.h, ctx, l.name, level, l.levelPC(callDepth+1))
+}
+
+// levelPC returns the pc of the log position if the Handler needs it to check the level, otherwise 0.
+//
+// 处理器判断级别时需要日志位置则返回其 pc, 否则返回 0.
+func (l *logger) levelPC(callDepth int) uintptr {
+	if !l.levelCaller {
+		return 0
+	}
+	return l.callerPC(callDepth + 1)
+}
+
+// callSiteKey is the ctx key of the callSite.
+//
+// callSite 在 ctx 上的键.
+type callSiteKey struct{}
+
+// callSite is set on the ctx by the adapters which log on behalf of another API, such as SlogHandler.
+// it is on the ctx so the Logger which wraps another Logger passes it along.
+//
+// 由代替其他接口打印日志的适配器(如 SlogHandler)设置在 ctx 上. 放在 ctx 上是为了让封装其他 Logger 的 Logger 也能传递它.
+type callSite struct {
+	pc       uintptr   // the log position       日志位置
+	time     time.Time // the log time, if not zero 日志时间, 非零值时使用
+	noSource bool      // Record.PC is set to 0  不输出源码信息
+	noExit   bool      // the Panic and Fatal logs are output without panic or exit 输出 Panic 及 Fatal 日志但不抛出 panic 或退出
+
+	// the level is decided by the adapter, the Handler does not check it again
+	// 级别由适配器决定, 处理器不再判断
+	levelChecked bool
+}
+
+func withCallSite(ctx context.Context, site callSite) context.Context {
+	return context.WithValue(ctx, callSiteKey{}, site)
+}
+
+func callSiteOf(ctx context.Context) (callSite, bool) {
+	if ctx == nil {
+		return callSite{}, false
+	}
+	site, ok := ctx.Value(callSiteKey{}).(callSite)
+	return site, ok
 }
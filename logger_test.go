@@ -0,0 +1,194 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/trie"
+)
+
+func TestLogger_Named(t *testing.T) {
+	var buf bytes.Buffer
+	levels := trie.NewSegmentTree(LevelInfo).Insert("db", LevelWarn).Insert("db.pool", LevelDebug)
+	root := NewLogger(NewHandler(WithWriter(&buf), WithName("handler"), WithLevels(levels)))
+	db := root.Named("db")
+	pool := db.Named("pool").Named("")
+
+	if root.Name() != "" || db.Name() != "db" || pool.Name() != "db.pool" {
+		t.Errorf("unexpected names: %q %q %q", root.Name(), db.Name(), pool.Name())
+	}
+	if db.Enable(LevelInfo) || !db.Enable(LevelWarn) {
+		t.Errorf("db logger should enable warn only")
+	}
+	if !pool.Enable(LevelDebug) || !pool.EnableContext(ctx, LevelDebug) {
+		t.Errorf("db.pool logger should enable debug")
+	}
+	db.Info(ctx, "db info")
+	pool.With("k", "v").Debug(ctx, "pool debug")
+	got := buf.String()
+	if strings.Contains(got, "db info") || !strings.Contains(got, "DEBUG [db.pool] ") {
+		t.Errorf("unexpected output: %s", got)
+	}
+
+	buf.Reset()
+	NewLogger(NewHandler(WithWriter(&buf), WithJSON())).Named("api").Info(ctx, "msg")
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil || m["logger"] != "api" {
+		t.Errorf("unexpected json output: %s", buf.String())
+	}
+	if got := formatRecord("%logger|%n", &Record{Name: "api"}); got != "api|\n" {
+		t.Errorf("formatRecord() = %q", got)
+	}
+}
+
+func TestLogger_AddCallerSkip(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf), WithFormat("%fun:%L%n")))
+	helper := func(l Logger) { l.Info(ctx, "msg") }
+	helper(logger.AddCallerSkip(1)) // line 51
+	helper(logger.AddCallerSkip(1).AddCallerSkip(-1))
+	if got := buf.String(); got != "TestLogger_AddCallerSkip:51\nTestLogger_AddCallerSkip.func1:50\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestWithCallerSkipPackages(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(WithWriter(&buf), WithFormat("%Pkg.%fun%n"))
+	// the closure of t.Run is called by testing.tRunner
+	t.Run("skip", func(t *testing.T) {
+		NewLogger(h, WithCallerSkipPackages("code.gopub.tech/logs/v2")).Info(ctx, "msg")
+		NewLogger(h, WithCallerSkipPackages("example.com/x")).Info(ctx, "msg")
+	})
+	if got := buf.String(); got != "testing.tRunner\ncode.gopub.tech/logs/v2.TestWithCallerSkipPackages.func1\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestWithoutCaller(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(WithWriter(&buf), WithoutCaller(), WithFormat("%Pkg|%F|%L %m%n"))
+	NewLogger(h).Info(ctx, "msg")
+	if got := buf.String(); got != "||0 msg\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+	if needCaller(h) || !needCaller(CombineHandlers(h, NewHandler())) || needCaller(CombineHandlers(h)) {
+		t.Errorf("unexpected NeedCaller")
+	}
+}
+
+// pcHandler records the pc passed to Enable.
+type pcHandler struct {
+	Handler
+	pcs []uintptr
+}
+
+func (h *pcHandler) Enable(level Level, pc uintptr) bool {
+	h.pcs = append(h.pcs, pc)
+	return h.Handler.Enable(level, pc)
+}
+
+func (h *pcHandler) levelNeedCaller(string) bool { return false }
+
+// countLevels counts the searches of the level.
+type countLevels struct{ n int }
+
+func (c *countLevels) Search(string) Level {
+	c.n++
+	return LevelInfo
+}
+
+func TestLogger_enableBeforeCaller(t *testing.T) {
+	h := &pcHandler{Handler: NewHandler(WithWriter(io.Discard))}
+	NewLogger(h).Debug(ctx, "disabled")
+	if len(h.pcs) != 1 || h.pcs[0] != 0 {
+		t.Errorf("the caller should not be got before checking the level: %v", h.pcs)
+	}
+
+	var buf bytes.Buffer
+	levels := new(countLevels)
+	l := NewLogger(NewHandler(WithWriter(&buf), WithLevels(levels), WithFormat("%Pkg %m%n")))
+	l.Named("db").Info(ctx, "msg")
+	if got := buf.String(); levels.n != 1 || got != "code.gopub.tech/logs/v2 msg\n" {
+		t.Errorf("the level should be checked once and the caller got after: n=%d, output=%q", levels.n, got)
+	}
+	l.Info(ctx, "by package") // 按包名查找级别时需要先获取调用位置
+	if h := l.(*logger).h; levels.n != 2 || !levelNeedCaller(h, "") || levelNeedCaller(h, "db") {
+		t.Errorf("the level by package should be checked once: n=%d", levels.n)
+	}
+	levels.n = 0
+	NewLogger(CombineHandlers(NewHandler(WithWriter(io.Discard), WithLevels(levels), WithName("a")),
+		NewHandler(WithWriter(io.Discard), WithLevel(LevelError)))).Info(ctx, "msg")
+	if levels.n != 2 {
+		t.Errorf("each of the combined handlers should check the level: n=%d", levels.n)
+	}
+}
+
+// fanoutHandler is a third-party handler which passes the Record to several handlers.
+type fanoutHandler []Handler
+
+func (f fanoutHandler) Output(r Record) {
+	for _, h := range f {
+		h.Output(r)
+	}
+}
+
+func (f fanoutHandler) Enable(level Level, pc uintptr) bool {
+	for _, h := range f {
+		if h.Enable(level, pc) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogger_thirdPartyFanout(t *testing.T) {
+	var info, errs bytes.Buffer
+	l := NewLogger(fanoutHandler{NewHandler(WithWriter(&info)), NewHandler(WithWriter(&errs), WithLevel(LevelError))})
+	l.Info(ctx, "info")
+	if !strings.Contains(info.String(), "info") || errs.Len() != 0 {
+		t.Errorf("each handler should check its own level: info=%q, error=%q", info.String(), errs.String())
+	}
+}
+
+func BenchmarkLogger_disabled(b *testing.B) {
+	loggers := []struct {
+		name   string
+		logger Logger
+	}{
+		{name: "caller", logger: NewLogger(NewHandler(WithWriter(io.Discard)))},
+		{name: "without-caller", logger: NewLogger(NewHandler(WithWriter(io.Discard), WithoutCaller()))},
+	}
+	for _, l := range loggers {
+		logger := l.logger
+		b.Run(l.name+"/Debug", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger.Debug(ctx, "disabled")
+			}
+		})
+		b.Run(l.name+"/DebugKV", func(b *testing.B) { // the Field is boxed at the call site 调用处装箱
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger.DebugKV(ctx, "disabled", F.Int("key", i))
+			}
+		})
+		b.Run(l.name+"/DebugFields", func(b *testing.B) { // only the []Field escapes through the interface 仅 []Field 经接口逃逸
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger.DebugFields(ctx, "disabled", F.Int("key", i))
+			}
+		})
+		b.Run(l.name+"/Enable", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if logger.Enable(LevelDebug) {
+					logger.DebugKV(ctx, "disabled", F.Int("key", i))
+				}
+			}
+		})
+	}
+}
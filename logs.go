@@ -15,39 +15,157 @@ func init() {
 func Default() Logger     { return defaultLogger.Load().(Logger) }
 func SetDefault(l Logger) { defaultLogger.Store(l) }
 
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx which carries the logger,
+// the package level functions such as `Info` use it instead of the Default Logger.
+//
+// 在 ctx 上设置 Logger, `Info` 等包级函数会使用该 Logger 而不是默认 Logger.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the Logger set by `NewContext`, or the Default Logger if not set.
+//
+// 获取 `NewContext` 设置的 Logger, 没有设置时返回默认 Logger.
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+			return l
+		}
+	}
+	return Default()
+}
+
 func With(key, value any) Logger {
 	return Default().With(key, value)
 }
 
+func WithKV(kvs ...any) Logger {
+	return Default().WithKV(kvs...)
+}
+
+func WithError(err error) Logger {
+	return Default().WithError(err)
+}
+
+func Named(name string) Logger {
+	return Default().Named(name)
+}
+
+func WithGroup(name string) Logger {
+	return Default().WithGroup(name)
+}
+
+func AddCallerSkip(n int) Logger {
+	return Default().AddCallerSkip(n)
+}
+
 func Trace(ctx context.Context, format string, args ...any) {
-	Default().Log(ctx, 1, LevelTrace, format, args...)
+	FromContext(ctx).Log(ctx, 1, LevelTrace, format, args...)
 }
 func Debug(ctx context.Context, format string, args ...any) {
-	Default().Log(ctx, 1, LevelDebug, format, args...)
+	FromContext(ctx).Log(ctx, 1, LevelDebug, format, args...)
 }
 func Info(ctx context.Context, format string, args ...any) {
-	Default().Log(ctx, 1, LevelInfo, format, args...)
+	FromContext(ctx).Log(ctx, 1, LevelInfo, format, args...)
 }
 func Notice(ctx context.Context, format string, args ...any) {
-	Default().Log(ctx, 1, LevelNotice, format, args...)
+	FromContext(ctx).Log(ctx, 1, LevelNotice, format, args...)
 }
 func Warn(ctx context.Context, format string, args ...any) {
-	Default().Log(ctx, 1, LevelWarn, format, args...)
+	FromContext(ctx).Log(ctx, 1, LevelWarn, format, args...)
 }
 func Error(ctx context.Context, format string, args ...any) {
-	Default().Log(ctx, 1, LevelError, format, args...)
+	FromContext(ctx).Log(ctx, 1, LevelError, format, args...)
 }
 func Panic(ctx context.Context, format string, args ...any) {
-	Default().Log(ctx, 1, LevelPanic, format, args...)
+	FromContext(ctx).Log(ctx, 1, LevelPanic, format, args...)
 }
 func Fatal(ctx context.Context, format string, args ...any) {
-	Default().Log(ctx, 1, LevelFatal, format, args...)
+	FromContext(ctx).Log(ctx, 1, LevelFatal, format, args...)
 }
 
 func Log(ctx context.Context, level Level, format string, args ...any) {
-	Default().Log(ctx, 1, level, format, args...)
+	FromContext(ctx).Log(ctx, 1, level, format, args...)
+}
+
+func TraceKV(ctx context.Context, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, LevelTrace, msg, kvs...)
+}
+func DebugKV(ctx context.Context, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, LevelDebug, msg, kvs...)
+}
+func InfoKV(ctx context.Context, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, LevelInfo, msg, kvs...)
+}
+func NoticeKV(ctx context.Context, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, LevelNotice, msg, kvs...)
+}
+func WarnKV(ctx context.Context, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, LevelWarn, msg, kvs...)
+}
+func ErrorKV(ctx context.Context, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, LevelError, msg, kvs...)
+}
+func PanicKV(ctx context.Context, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, LevelPanic, msg, kvs...)
+}
+func FatalKV(ctx context.Context, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, LevelFatal, msg, kvs...)
+}
+
+func LogKV(ctx context.Context, level Level, msg string, kvs ...any) {
+	FromContext(ctx).LogKV(ctx, 1, level, msg, kvs...)
+}
+
+func TraceFields(ctx context.Context, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, LevelTrace, msg, fs...)
+}
+func DebugFields(ctx context.Context, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, LevelDebug, msg, fs...)
+}
+func InfoFields(ctx context.Context, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, LevelInfo, msg, fs...)
+}
+func NoticeFields(ctx context.Context, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, LevelNotice, msg, fs...)
+}
+func WarnFields(ctx context.Context, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, LevelWarn, msg, fs...)
+}
+func ErrorFields(ctx context.Context, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, LevelError, msg, fs...)
+}
+func PanicFields(ctx context.Context, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, LevelPanic, msg, fs...)
+}
+func FatalFields(ctx context.Context, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, LevelFatal, msg, fs...)
+}
+
+func LogFields(ctx context.Context, level Level, msg string, fs ...Field) {
+	FromContext(ctx).LogFields(ctx, 1, level, msg, fs...)
 }
 
 func Enable(level Level) bool {
 	return Default().EnableDepth(level, 1)
 }
+
+func EnableContext(ctx context.Context, level Level) bool {
+	return FromContext(ctx).EnableContextDepth(ctx, level, 1)
+}
+
+// Log output a log at this level with the Logger of the ctx (see `FromContext`), useful for registered custom levels.
+//
+// 使用 ctx 上的 Logger (参见 `FromContext`) 输出该级别的日志, 便于使用自定义级别. 参见 `RegisterLevel`.
+func (l Level) Log(ctx context.Context, format string, args ...any) {
+	FromContext(ctx).Log(ctx, 1, l, format, args...)
+}
+
+// LogTo output a log at this level with the logger.
+//
+// 使用指定 Logger 输出该级别的日志.
+func (l Level) LogTo(ctx context.Context, logger Logger, format string, args ...any) {
+	logger.Log(ctx, 1, l, format, args...)
+}
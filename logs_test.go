@@ -3,11 +3,12 @@ package logs_test
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 
-	"code.gopub.tech/logs"
-	"code.gopub.tech/logs/pkg/kv"
-	"code.gopub.tech/logs/pkg/trie"
+	"code.gopub.tech/logs/v2"
+	"code.gopub.tech/logs/v2/pkg/kv"
+	"code.gopub.tech/logs/v2/pkg/trie"
 )
 
 var ctx = context.Background()
@@ -42,7 +43,7 @@ func TestLog(t *testing.T) {
 		// logs.WithLevel(logs.LevelDebug), // global level, default info
 		logs.WithLevels(
 			trie.NewTree(logs.LevelInfo).
-				Insert("code.gopub.tech/logs_test", logs.LevelDebug),
+				Insert("code.gopub.tech/logs/v2_test", logs.LevelDebug),
 		), // set log level on package
 	)) // new logger
 	logs.SetDefault(logger)                  // global default logger
@@ -57,6 +58,8 @@ func TestLog(t *testing.T) {
 	// logs.Panic(ctx, "Global: PanicMessage")
 	// logs.Fatal(ctx, "Global: FatalMessage")
 	logs.Log(ctx, logs.LevelInfo, "Global: Hello, %s", "World")
+	logs.InfoFields(ctx, "Global: InfoFields", logs.F.Int("n", 1))
+	logs.LogFields(ctx, logs.LevelWarn, "Global: LogFields", logs.F.String("s", "v"))
 	logs.With("key", "value").Info(ctx, "Logger: With Key-Value")
 	// logger methods
 	logger.Trace(ctx, "Logger: TraceMessage")
@@ -78,11 +81,32 @@ func TestLog(t *testing.T) {
 	}()
 	((*S)(nil)).Func() // log Record fun: (*S).Func
 	t.Log(sb.String())
+	assert(t, strings.Contains(sb.String(), "n=1 num=42 100=abc Global: InfoFields"))
 	// logs.Fatal(ctx, "Fatalmsg")
 
-	assert(t, logs.Enable(logs.LevelDebug))                     // code.gopub.tech/logs_test this package enable Debug level.
-	assert(t, logger.Enable(logs.LevelDebug))                   // code.gopub.tech/logs_test this package enable Debug level.
+	assert(t, logs.Enable(logs.LevelDebug))                     // code.gopub.tech/logs/v2_test this package enable Debug level.
+	assert(t, logger.Enable(logs.LevelDebug))                   // code.gopub.tech/logs/v2_test this package enable Debug level.
 	assert(t, logger.EnableDepth(logs.LevelDebug, 1) == false)  // callDepth=1 --> tesing.tRunner
 	assert(t, logger.EnableDepth(logs.LevelDebug, -1) == false) // callDepth=-1 --> logs.EnableDepth
 
 }
+
+func TestNewContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logs.NewLogger(logs.NewHandler(logs.WithWriter(&buf), logs.WithLevel(logs.LevelDebug))).
+		Named("req").With("rid", 1)
+	if logs.FromContext(ctx) != logs.Default() || logs.FromContext(nil) != logs.Default() {
+		t.Errorf("FromContext() should fallback to Default()")
+	}
+	reqCtx := logs.NewContext(context.Background(), logger)
+	if logs.FromContext(reqCtx) != logger {
+		t.Errorf("FromContext() should return the logger")
+	}
+	logs.Debug(reqCtx, "debug")
+	logs.InfoKV(reqCtx, "info", "k", "v")
+	logs.LevelNotice.Log(reqCtx, "notice")
+	assert(t, logs.EnableContext(reqCtx, logs.LevelDebug))
+	got := buf.String()
+	assert(t, bytes.Count(buf.Bytes(), []byte("[req] ")) == 3, got)
+	assert(t, bytes.Contains(buf.Bytes(), []byte("rid=1 k=v info")), got)
+}
@@ -0,0 +1,170 @@
+package logs
+
+import (
+	"go/build"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"code.gopub.tech/logs/v2/pkg/caller"
+)
+
+// PathFunc returns the source path to output for the frame, instead of the absolute build path.
+//
+// 返回日志中输出的源码路径, 用于替代编译时的绝对路径.
+type PathFunc func(frame caller.Frame) string
+
+// WithPath set how to output the source path, it is used by the text, json output
+// and the %path placeholder of `WithFormat`. see `ModuleRelativePath`, `GopathRelativePath`, `LastNPath`.
+//
+// 设置源码路径的输出方式, 作用于文本、json 格式以及 `WithFormat` 的 %path 占位符.
+// 参见 `ModuleRelativePath`, `GopathRelativePath`, `LastNPath`.
+func WithPath(fn PathFunc) Option { return func(h *handler) { h.path = fn } }
+
+// ModuleRelativePath returns the path relative to the module root read from the build info,
+// the same as `go build -trimpath`: `sub/dir` for the main module (`.` for the root)
+// and `module@version/sub/dir` for the dependencies.
+// the path not in any module (such as the standard library) falls back to `GopathRelativePath`.
+//
+// 返回相对于模块根目录的路径, 模块信息读取自编译信息. 与 `go build -trimpath` 一致:
+// 主模块为 `sub/dir` (根目录为 `.`), 依赖模块为 `module@version/sub/dir`.
+// 不在模块中的路径(如标准库)使用 `GopathRelativePath` 处理.
+func ModuleRelativePath(frame caller.Frame) string {
+	mods := buildModules()
+	pkg := strings.TrimSuffix(frame.Pkg, "_test") // 外部测试包
+	if pkg == "main" {
+		pkg = mods.mainPkg
+	}
+	for _, mod := range mods.list { // 最长的模块路径在前
+		if pkg != mod.path && !strings.HasPrefix(pkg, mod.path+"/") {
+			continue
+		}
+		sub := pkg[len(mod.path):] // "" or /sub/dir
+		if !strings.HasSuffix(frame.Path, sub) {
+			break // 目录结构与包路径不一致
+		}
+		if mod.main {
+			if sub == "" {
+				return "."
+			}
+			return sub[1:]
+		}
+		return mod.path + "@" + mod.version + sub
+	}
+	return GopathRelativePath(frame)
+}
+
+// GopathRelativePath returns the path relative to GOROOT/src, the module cache (GOPATH/pkg/mod) or GOPATH/src,
+// such as `net/http` and `gopkg.in/natefinch/lumberjack.v2@v2.2.1`. the other paths are not changed.
+//
+// 返回相对于 GOROOT/src, 模块缓存(GOPATH/pkg/mod) 或 GOPATH/src 的路径,
+// 如 `net/http` 及 `gopkg.in/natefinch/lumberjack.v2@v2.2.1`. 其他路径保持不变.
+func GopathRelativePath(frame caller.Frame) string {
+	path := frame.Path
+	if goroot := gorootSrc(); goroot != "" && strings.HasPrefix(path, goroot) {
+		return path[len(goroot):]
+	}
+	if i := strings.Index(path, "/pkg/mod/"); i >= 0 {
+		return path[i+len("/pkg/mod/"):]
+	}
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		if src := filepath.ToSlash(gopath) + "/src/"; strings.HasPrefix(path, src) {
+			return path[len(src):]
+		}
+	}
+	return path
+}
+
+// LastNPath returns a PathFunc which keeps the last n directories of the path,
+// e.g. `LastNPath(2)` outputs `pkg/caller` for `/home/user/logs/pkg/caller`.
+//
+// 返回一个仅保留路径中最后 n 级目录的 PathFunc, 如 `LastNPath(2)` 将 `/home/user/logs/pkg/caller` 输出为 `pkg/caller`.
+func LastNPath(n int) PathFunc {
+	return func(frame caller.Frame) string {
+		path, dirs := frame.Path, n
+		if dirs <= 0 {
+			return ""
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			if path[i] == '/' {
+				if dirs--; dirs == 0 {
+					return path[i+1:]
+				}
+			}
+		}
+		return path
+	}
+}
+
+// frameOf returns the frame of the pc, the path is rewritten by the PathFunc if it is not nil.
+//
+// 返回 pc 对应的调用帧, PathFunc 不为空时使用其改写路径.
+func frameOf(pc uintptr, path PathFunc) caller.Frame {
+	f := caller.GetFrame(pc)
+	if path != nil && f.Path != "" {
+		f.Path = path(f)
+	}
+	return f
+}
+
+type module struct {
+	path, version string
+	main          bool
+}
+
+type modules struct {
+	mainPkg string   // import path of the main package 主包的导入路径
+	list    []module // sorted by path length desc 按路径长度倒序
+}
+
+var (
+	modulesOnce sync.Once
+	modulesInfo modules
+	gorootOnce  sync.Once
+	gorootPath  string
+)
+
+// buildModules returns the modules read from the build info.
+//
+// 返回编译信息中的模块.
+func buildModules() *modules {
+	modulesOnce.Do(func() { modulesInfo = readModules() })
+	return &modulesInfo
+}
+
+func readModules() (mods modules) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return mods
+	}
+	mods.mainPkg = strings.TrimSuffix(info.Path, ".test") // go test
+	if info.Main.Path != "" {
+		mods.list = append(mods.list, module{path: info.Main.Path, main: true})
+	}
+	for _, dep := range info.Deps {
+		mods.list = append(mods.list, module{path: dep.Path, version: dep.Version})
+	}
+	sort.Slice(mods.list, func(i, j int) bool { return len(mods.list[i].path) > len(mods.list[j].path) })
+	return mods
+}
+
+// gorootSrc returns the GOROOT/src with a trailing slash, it is read from the source path of the runtime package,
+// so it is the GOROOT on the build machine.
+//
+// 返回以斜杠结尾的 GOROOT/src. 读取自 runtime 包的源码路径, 因此是编译机器上的 GOROOT.
+func gorootSrc() string {
+	gorootOnce.Do(func() {
+		pc := reflect.ValueOf(runtime.Gosched).Pointer()
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			file, _ := fn.FileLine(pc)
+			if i := strings.LastIndex(file, "/runtime/"); i >= 0 {
+				gorootPath = file[:i+1]
+			}
+		}
+	})
+	return gorootPath
+}
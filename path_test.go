@@ -0,0 +1,106 @@
+package logs
+
+import (
+	"bytes"
+	"go/build"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/caller"
+)
+
+func TestModuleRelativePath(t *testing.T) {
+	var lumberjack string
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "gopkg.in/natefinch/lumberjack.v2" {
+				lumberjack = dep.Path + "@" + dep.Version
+			}
+		}
+	}
+	dir, _ := filepath.Abs(".")
+	tests := []struct {
+		name  string
+		frame caller.Frame
+		want  string
+	}{
+		{"root", caller.GetFrame(caller.PC(0)), "."},
+		{"sub", caller.GetFrame(caller.PC(-1)), "pkg/caller"},
+		{"dep", caller.Frame{Pkg: "gopkg.in/natefinch/lumberjack.v2", Path: "/go/pkg/mod/" + lumberjack}, lumberjack},
+		{"std", caller.Frame{Pkg: "net/http", Path: gorootSrc() + "net/http"}, "net/http"},
+		{"mismatch", caller.Frame{Pkg: "code.gopub.tech/logs/v2/pkg/caller", Path: dir + "/other"}, dir + "/other"},
+		{"unknown", caller.Frame{Pkg: "example.com/x", Path: "/src/x"}, "/src/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ModuleRelativePath(tt.frame); got != tt.want {
+				t.Errorf("ModuleRelativePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGopathRelativePath(t *testing.T) {
+	gopath := filepath.ToSlash(filepath.SplitList(build.Default.GOPATH)[0])
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"goroot", gorootSrc() + "net/http", "net/http"},
+		{"mod", "/home/user/go/pkg/mod/gopkg.in/natefinch/lumberjack.v2@v2.2.1", "gopkg.in/natefinch/lumberjack.v2@v2.2.1"},
+		{"gopath", gopath + "/src/example.com/x", "example.com/x"},
+		{"other", "/home/user/x", "/home/user/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GopathRelativePath(caller.Frame{Path: tt.path}); got != tt.want {
+				t.Errorf("GopathRelativePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastNPath(t *testing.T) {
+	tests := []struct {
+		n    int
+		path string
+		want string
+	}{
+		{0, "/home/user/logs/pkg/caller", ""},
+		{1, "/home/user/logs/pkg/caller", "caller"},
+		{2, "/home/user/logs/pkg/caller", "pkg/caller"},
+		{5, "/home/user/logs/pkg/caller", "home/user/logs/pkg/caller"},
+		{6, "/home/user/logs/pkg/caller", "/home/user/logs/pkg/caller"},
+		{2, "caller", "caller"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := LastNPath(tt.n)(caller.Frame{Path: tt.path}); got != tt.want {
+				t.Errorf("LastNPath(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPath(t *testing.T) {
+	var buf bytes.Buffer
+	NewLogger(NewHandler(WithWriter(&buf), WithPath(ModuleRelativePath))).Info(ctx, "text")
+	NewLogger(NewHandler(WithWriter(&buf), WithPath(LastNPath(1)), WithJSON())).Info(ctx, "json")
+	NewLogger(NewHandler(WithWriter(&buf), WithPath(LastNPath(1)), WithFormat("%path/%F%n"))).Info(ctx, "format")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Base(wd) // 检出目录的名称
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 4 ||
+		!strings.Contains(lines[0], " code.gopub.tech/logs/v2.TestWithPath ./path_test.go:") ||
+		!strings.Contains(lines[1], `"path":"`+base+`"`) ||
+		lines[2] != base+"/path_test.go" {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
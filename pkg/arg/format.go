@@ -0,0 +1,53 @@
+package arg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Text is a deferred string value, the function is called when it is formatted,
+// it is output as the string both in text and json.
+//
+// 延迟计算的字符串值, 格式化时才会调用该函数. 文本及 json 格式中都以该字符串输出.
+type Text func() string
+
+func (t Text) String() string { return t() }
+
+// Format implements fmt.Formatter, %v and %s print the string, %#v and %+v are the same as %v,
+// the other verbs are the same as formatting a string.
+//
+// 实现 fmt.Formatter, %v 及 %s 输出该字符串, %#v 及 %+v 与 %v 相同, 其他动词与格式化字符串相同.
+func (t Text) Format(f fmt.State, verb rune) { formatText(f, verb, t()) }
+
+func (t Text) MarshalJSON() ([]byte, error) { return json.Marshal(t()) }
+
+// formatText format the text as a string, %v is the same as %s.
+//
+// 以字符串形式格式化, %v 与 %s 相同.
+func formatText(f fmt.State, verb rune, s string) {
+	if verb == 'v' {
+		verb = 's'
+	}
+	fmt.Fprintf(f, formatString(f, verb, "-0 "), s)
+}
+
+// formatString rebuild the format string such as `%-10.2s` from the state, only the flags are kept.
+//
+// 从 fmt.State 中还原格式化字符串, 如 `%-10.2s`, 仅保留 flags 中的标志.
+func formatString(f fmt.State, verb rune, flags string) string {
+	b := []byte{'%'}
+	for i := 0; i < len(flags); i++ {
+		if f.Flag(int(flags[i])) {
+			b = append(b, flags[i])
+		}
+	}
+	if w, ok := f.Width(); ok {
+		b = strconv.AppendInt(b, int64(w), 10)
+	}
+	if p, ok := f.Precision(); ok {
+		b = append(b, '.')
+		b = strconv.AppendInt(b, int64(p), 10)
+	}
+	return string(append(b, string(verb)...))
+}
@@ -1,22 +1,47 @@
 package arg
 
 import (
-	"encoding/json"
 	"fmt"
+
+	"code.gopub.tech/logs/v2/pkg/redact"
 )
 
 type Arg struct {
-	data any
+	data   any
+	indent bool
 }
 
 func JSON(data any) *Arg {
-	return &Arg{data}
+	return &Arg{data: data}
+}
+
+// Indent is like JSON, but the text is indented. the json output is compact.
+//
+// 与 JSON 类似, 但文本是缩进的. json 格式输出是紧凑的.
+func Indent(data any) *Arg {
+	return &Arg{data: data, indent: true}
 }
 
 func (a *Arg) String() string {
-	b, err := json.Marshal(a.data)
+	var b []byte
+	var err error
+	if a.indent {
+		b, err = redact.MarshalIndent(a.data, "", "  ")
+	} else {
+		b, err = redact.Marshal(a.data)
+	}
 	if err != nil {
-		return fmt.Sprintf("!(BADJSON|err=%+v|data=%#v)", err, a.data)
+		return fmt.Sprintf("!(BADJSON|err=%+v|data=%#v)", err, redact.Struct(a.data))
 	}
 	return string(b)
 }
+
+// Format implements fmt.Formatter, %v, %s, %#v and %+v print the json text, see `Text.Format`.
+//
+// 实现 fmt.Formatter, %v, %s, %#v 及 %+v 均输出 json 文本, 参见 `Text.Format`.
+func (a *Arg) Format(f fmt.State, verb rune) { formatText(f, verb, a.String()) }
+
+// MarshalJSON returns the json of the data, so it is embedded as is in json output.
+//
+// 返回数据的 json, 以便在 json 格式输出中原样嵌入.
+func (a *Arg) MarshalJSON() ([]byte, error) { return redact.Marshal(a.data) }
@@ -1,10 +1,11 @@
 package arg_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 
-	"code.gopub.tech/logs/pkg/arg"
+	"code.gopub.tech/logs/v2/pkg/arg"
 )
 
 func TestJSON(t *testing.T) {
@@ -30,3 +31,27 @@ func TestJSON(t *testing.T) {
 	t.Logf(" %%v: %s", fmt.Sprintf("%v", arg.JSON(m)))
 	t.Logf(" %%s: %s", fmt.Sprintf("%s", arg.JSON(m)))
 }
+
+func TestJSON_Format(t *testing.T) {
+	data := struct{ ID int }{ID: 1}
+	for _, tCase := range []struct {
+		format string
+		arg    any
+		want   string
+	}{
+		{"%v", arg.JSON(data), `{"ID":1}`},
+		{"%#v", arg.JSON(data), `{"ID":1}`},
+		{"%+v", arg.JSON(data), `{"ID":1}`},
+		{"%q", arg.JSON(data), `"{\"ID\":1}"`},
+		{"%10v", arg.JSON(1), `         1`},
+		{"%v", arg.Indent(data), "{\n  \"ID\": 1\n}"},
+	} {
+		if got := fmt.Sprintf(tCase.format, tCase.arg); got != tCase.want {
+			t.Errorf("%s: got= %q want = %q", tCase.format, got, tCase.want)
+		}
+	}
+	b, err := json.Marshal(map[string]any{"a": arg.JSON(data), "b": arg.Indent(data)})
+	if err != nil || string(b) != `{"a":{"ID":1},"b":{"ID":1}}` {
+		t.Errorf("json.Marshal() = %s, %v", b, err)
+	}
+}
@@ -0,0 +1,71 @@
+package arg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LazyFunc is a deferred value, the function is called each time it is formatted.
+//
+// 延迟计算的值, 每次格式化时都会调用该函数.
+type LazyFunc func() any
+
+// Lazy defer the fn until the value is formatted, so nothing is computed if the log is not output.
+//
+//	logs.Debug(ctx, "state: %v", arg.Lazy(func() any { return expensive() }))
+//
+// 延迟到格式化时才调用 fn, 日志不输出时不会进行计算.
+func Lazy(fn func() any) LazyFunc { return fn }
+
+func (fn LazyFunc) String() string { return fmt.Sprint(fn()) }
+
+// Format implements fmt.Formatter, the value is formatted with the same verb and flags, so %#v works.
+//
+// 实现 fmt.Formatter, 使用相同的动词及标志格式化该值, 因此 %#v 也可以使用.
+func (fn LazyFunc) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, formatString(f, verb, "-+# 0"), fn())
+}
+
+func (fn LazyFunc) MarshalJSON() ([]byte, error) { return json.Marshal(fn()) }
+
+// Sprintf defer the fmt.Sprintf until the value is formatted.
+//
+// 延迟到格式化时才调用 fmt.Sprintf.
+func Sprintf(format string, args ...any) Text {
+	return func() string { return fmt.Sprintf(format, args...) }
+}
+
+// Stringer batch the items, it is output as `[a b c]` in text and `["a","b","c"]` in json,
+// the String methods are called when it is formatted.
+//
+// 批量输出 Stringer, 文本格式为 `[a b c]`, json 格式为 `["a","b","c"]`, 格式化时才调用 String 方法.
+func Stringer[T fmt.Stringer](items ...T) Stringers[T] { return items }
+
+// Stringers is a batch of fmt.Stringer, see `Stringer`.
+//
+// 一组 fmt.Stringer, 参见 `Stringer`.
+type Stringers[T fmt.Stringer] []T
+
+func (s Stringers[T]) String() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, item := range s {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(item.String())
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+func (s Stringers[T]) Format(f fmt.State, verb rune) { formatText(f, verb, s.String()) }
+
+func (s Stringers[T]) MarshalJSON() ([]byte, error) {
+	list := make([]string, len(s))
+	for i, item := range s {
+		list[i] = item.String()
+	}
+	return json.Marshal(list)
+}
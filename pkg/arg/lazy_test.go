@@ -0,0 +1,63 @@
+package arg_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/arg"
+)
+
+func TestLazy(t *testing.T) {
+	called := 0
+	lazy := arg.Lazy(func() any {
+		called++
+		return struct{ ID int }{ID: 1}
+	})
+	if called != 0 {
+		t.Errorf("should not be called before formatting")
+	}
+	for _, tCase := range []struct {
+		format string
+		want   string
+	}{
+		{"%v", "{1}"},
+		{"%+v", "{ID:1}"},
+		{"%#v", "struct { ID int }{ID:1}"},
+		{"%s", "{%!s(int=1)}"},
+	} {
+		if got := fmt.Sprintf(tCase.format, lazy); got != tCase.want {
+			t.Errorf("%s: got= %q want = %q", tCase.format, got, tCase.want)
+		}
+	}
+	if got, err := json.Marshal(lazy); err != nil || string(got) != `{"ID":1}` {
+		t.Errorf("json got= %s, err=%v", got, err)
+	}
+	if lazy.String() != "{1}" || called != 6 {
+		t.Errorf("String() = %q, called %d times", lazy.String(), called)
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	s := arg.Sprintf("%d-%s", 1, "a")
+	if got := fmt.Sprintf("%v|%#v|%q", s, s, s); got != `1-a|1-a|"1-a"` {
+		t.Errorf("got= %q", got)
+	}
+	if got, err := json.Marshal(s); err != nil || string(got) != `"1-a"` {
+		t.Errorf("json got= %s, err=%v", got, err)
+	}
+}
+
+func TestStringer(t *testing.T) {
+	ips := arg.Stringer(net.IPv4(127, 0, 0, 1), net.IPv4(10, 0, 0, 1))
+	if got := fmt.Sprintf("%v|%#v", ips, ips); got != "[127.0.0.1 10.0.0.1]|[127.0.0.1 10.0.0.1]" {
+		t.Errorf("got= %q", got)
+	}
+	if got, err := json.Marshal(ips); err != nil || string(got) != `["127.0.0.1","10.0.0.1"]` {
+		t.Errorf("json got= %s, err=%v", got, err)
+	}
+	if got := fmt.Sprint(arg.Stringer[net.IP]()); got != "[]" {
+		t.Errorf("empty got= %q", got)
+	}
+}
@@ -0,0 +1,77 @@
+package arg
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Hex output the bytes as lower case hex.
+//
+// 以小写十六进制形式输出.
+func Hex(b []byte) Text {
+	return func() string { return hex.EncodeToString(b) }
+}
+
+// Truncate output the first n runes of s, followed by `...(total N)` if it is truncated.
+//
+// 输出 s 的前 n 个字符, 被截断时在之后追加 `...(total N)`.
+func Truncate(s string, n int) Text {
+	return func() string {
+		if len(s) <= n {
+			return s
+		}
+		count := 0
+		for i := range s {
+			if count >= n {
+				return s[:i] + "...(total " + strconv.Itoa(utf8.RuneCountInString(s)) + ")"
+			}
+			count++
+		}
+		return s
+	}
+}
+
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// Bytes output the size in human readable IEC units with at most 2 decimals, such as `512B`, `1.5KiB`, `10MiB`.
+//
+// 以易读的 IEC 单位输出大小, 如 `512B`, `1.5KiB`, `10MiB`.
+func Bytes(n int64) Text {
+	return func() string {
+		size, unit, sign := float64(n), 0, ""
+		if size < 0 {
+			size, sign = -size, "-"
+		}
+		for size >= 1024-0.005 && unit < len(byteUnits)-1 { // 1023.999 舍入后是 1024, 应进位到下一单位
+			size /= 1024
+			unit++
+		}
+		s := strconv.FormatFloat(size, 'f', 2, 64) // 最多保留两位小数
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+		return sign + s + byteUnits[unit]
+	}
+}
+
+// Duration output the duration rounded to 3 significant decimals of its unit, such as `1.235s` and `12.346ms`,
+// it is a string in json, instead of the nanoseconds of time.Duration.
+//
+// 输出保留三位小数的时长, 如 `1.235s`, `12.346ms`. json 格式输出字符串, 而不是 time.Duration 的纳秒数.
+func Duration(d time.Duration) Text {
+	return func() string {
+		abs := d
+		if abs < 0 {
+			abs = -abs
+		}
+		switch {
+		case abs >= time.Second:
+			return d.Round(time.Millisecond).String()
+		case abs >= time.Millisecond:
+			return d.Round(time.Microsecond).String()
+		default:
+			return d.String()
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package arg_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"code.gopub.tech/logs/v2/pkg/arg"
+)
+
+func TestText(t *testing.T) {
+	for _, tCase := range []struct {
+		name     string
+		arg      arg.Text
+		want     string
+		wantJSON string
+	}{
+		{"hex", arg.Hex([]byte{0xde, 0xad, 0xbe, 0xef}), "deadbeef", `"deadbeef"`},
+		{"hex-empty", arg.Hex(nil), "", `""`},
+		{"truncate", arg.Truncate("hello, world", 5), "hello...(total 12)", `"hello...(total 12)"`},
+		{"truncate-rune", arg.Truncate("你好世界", 2), "你好...(total 4)", `"你好...(total 4)"`},
+		{"truncate-short", arg.Truncate("你好", 2), "你好", `"你好"`},
+		{"truncate-negative", arg.Truncate("hi", -1), "...(total 2)", `"...(total 2)"`},
+		{"bytes", arg.Bytes(512), "512B", `"512B"`},
+		{"bytes-kib", arg.Bytes(1536), "1.5KiB", `"1.5KiB"`},
+		{"bytes-mib", arg.Bytes(10 << 20), "10MiB", `"10MiB"`},
+		{"bytes-round", arg.Bytes(1<<30 - 1), "1GiB", `"1GiB"`},
+		{"bytes-min", arg.Bytes(math.MinInt64), "-8EiB", `"-8EiB"`},
+		{"bytes-negative", arg.Bytes(-2048), "-2KiB", `"-2KiB"`},
+		{"duration", arg.Duration(1234567891), "1.235s", `"1.235s"`},
+		{"duration-ms", arg.Duration(12345678), "12.346ms", `"12.346ms"`},
+		{"duration-us", arg.Duration(1234 * time.Nanosecond), "1.234µs", `"1.234µs"`},
+		{"duration-negative", arg.Duration(-1234567891), "-1.235s", `"-1.235s"`},
+	} {
+		t.Run(tCase.name, func(t *testing.T) {
+			if got := fmt.Sprintf("%v", tCase.arg); got != tCase.want {
+				t.Errorf("%%v got= %q want = %q", got, tCase.want)
+			}
+			if got := fmt.Sprintf("%#v", tCase.arg); got != tCase.want {
+				t.Errorf("%%#v got= %q want = %q", got, tCase.want)
+			}
+			if got, err := json.Marshal(tCase.arg); err != nil || string(got) != tCase.wantJSON {
+				t.Errorf("json got= %s want = %s, err=%v", got, tCase.wantJSON, err)
+			}
+		})
+	}
+	if got := fmt.Sprintf("[%-6s|%6.2v|%q]", arg.Hex([]byte{1}), arg.Hex([]byte{2, 3}), arg.Hex([]byte{4})); got != `[01    |    02|"04"]` {
+		t.Errorf("got= %q", got)
+	}
+}
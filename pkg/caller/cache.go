@@ -0,0 +1,35 @@
+package caller
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// maxCacheSize is the max number of the cached frames, the frames after it are not cached.
+// the pcs of a program are limited, so it is rarely reached.
+//
+// 缓存的最大帧数, 超出后不再缓存. 程序中的 pc 是有限的, 通常不会达到该上限.
+const maxCacheSize = 1 << 14
+
+// frameCache is a concurrent pc→Frame cache with bounded size.
+//
+// 并发安全、有容量上限的 pc→Frame 缓存.
+type frameCache struct {
+	m    sync.Map // map[uintptr]Frame
+	size int64
+}
+
+var cache frameCache
+
+func (c *frameCache) Load(pc uintptr) (any, bool) {
+	return c.m.Load(pc)
+}
+
+func (c *frameCache) Store(pc uintptr, f Frame) {
+	if atomic.LoadInt64(&c.size) >= maxCacheSize {
+		return
+	}
+	if _, loaded := c.m.LoadOrStore(pc, f); !loaded {
+		atomic.AddInt64(&c.size, 1)
+	}
+}
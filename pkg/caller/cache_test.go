@@ -0,0 +1,50 @@
+package caller
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGetFrame_cache(t *testing.T) {
+	pc := PC(0)
+	if got, want := GetFrame(pc), resolveFrame(pc); got != want {
+		t.Errorf("GetFrame() = %v, want %v", got, want)
+	}
+	if _, ok := cache.Load(pc); !ok {
+		t.Errorf("pc not cached")
+	}
+	var c frameCache
+	pcs := make([]uintptr, maxCacheSize+1)
+	for i := range pcs {
+		pcs[i] = uintptr(i + 1)
+		c.Store(pcs[i], Frame{PC: pcs[i]})
+	}
+	c.Store(pcs[0], Frame{PC: pcs[0]}) // store again 重复存储不计数
+	if c.size != maxCacheSize {
+		t.Errorf("size = %d, want %d", c.size, maxCacheSize)
+	}
+	if _, ok := c.Load(pcs[maxCacheSize]); ok {
+		t.Errorf("cached over the max size")
+	}
+}
+
+func BenchmarkGetFrame(b *testing.B) {
+	pcs := make([]uintptr, 5)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				GetFrame(pcs[i%len(pcs)])
+			}
+		})
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				resolveFrame(pcs[i%len(pcs)])
+			}
+		})
+	})
+}
@@ -7,26 +7,35 @@ import (
 
 type Frame struct {
 	PC         uintptr
-	Pkg, Fun   string
+	Pkg, Fun   string // Fun is the function part of the name, such as (*T).Method.func1 函数名中包路径之后的部分
 	Path, File string
 	Line       int
+	Recv       string // receiver type, such as *T, empty for function 接收者类型, 函数时为空
+	Method     string // method or function name, such as Method 方法名或函数名
+	Closure    int    // closure depth, such as 2 for Fn.func1.2 闭包嵌套层数
 }
 
-func GetFrame(pc uintptr) (f Frame) {
+// GetFrame returns the Frame of the pc, the result is cached, see `maxCacheSize`.
+//
+// 获取 pc 对应的调用帧, 结果会被缓存.
+func GetFrame(pc uintptr) Frame {
+	if f, ok := cache.Load(pc); ok {
+		return f.(Frame)
+	}
+	f := resolveFrame(pc)
+	cache.Store(pc, f)
+	return f
+}
+
+// resolveFrame resolve the Frame of the pc without cache.
+//
+// 不使用缓存, 解析 pc 对应的调用帧.
+func resolveFrame(pc uintptr) (f Frame) {
 	f.PC = pc
 	frames := runtime.CallersFrames([]uintptr{pc})
 	frame, _ := frames.Next()
-	f.Fun = frame.Function
-	if index := strings.Index(f.Fun, "/"); index > 0 {
-		// code.gopub.tech/logs.pc
-		f.Pkg = f.Fun[:index+1] // with /
-		f.Fun = f.Fun[index+1:]
-	}
-	if index := strings.Index(f.Fun, "."); index > 0 {
-		// main.main
-		f.Pkg += f.Fun[:index]
-		f.Fun = f.Fun[index+1:]
-	}
+	f.Pkg, f.Fun = splitFuncName(frame.Function)
+	f.Recv, f.Method, f.Closure = parseFun(f.Fun)
 	f.File = frame.File
 	if index := strings.LastIndex(f.File, "/"); index >= 0 {
 		f.Path = f.File[:index]
@@ -3,9 +3,10 @@ package caller_test
 import (
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"testing"
 
-	"code.gopub.tech/logs/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/caller"
 )
 
 var (
@@ -13,7 +14,7 @@ var (
 )
 
 func TestGetFrame(t *testing.T) {
-	pc := caller.PC(0) // line 16
+	pc := caller.PC(0) // line 17
 	type args struct {
 		pc uintptr
 	}
@@ -38,24 +39,26 @@ func TestGetFrame(t *testing.T) {
 			name: "case1",
 			args: args{caller.PC(-1)},
 			wantF: caller.Frame{
-				PC:   caller.PC(-1),
-				Pkg:  "code.gopub.tech/logs/pkg/caller",
-				Fun:  "PC",
-				Path: dir,
-				File: "pc.go",
-				Line: 10,
+				PC:     caller.PC(-1),
+				Pkg:    "code.gopub.tech/logs/v2/pkg/caller",
+				Fun:    "PC",
+				Path:   dir,
+				File:   "pc.go",
+				Line:   10,
+				Method: "PC",
 			},
 		},
 		{
 			name: "case2",
 			args: args{pc},
 			wantF: caller.Frame{
-				PC:   pc,
-				Pkg:  "code.gopub.tech/logs/pkg/caller_test",
-				Fun:  "TestGetFrame",
-				Path: dir,
-				File: "frame_test.go",
-				Line: 16,
+				PC:     pc,
+				Pkg:    "code.gopub.tech/logs/v2/pkg/caller_test",
+				Fun:    "TestGetFrame",
+				Path:   dir,
+				File:   "frame_test.go",
+				Line:   17,
+				Method: "TestGetFrame",
 			},
 		},
 	}
@@ -67,3 +70,52 @@ func TestGetFrame(t *testing.T) {
 		})
 	}
 }
+
+type T struct{}
+
+func (T) Value() uintptr    { return caller.PC(0) }
+func (*T) Pointer() uintptr { return caller.PC(0) }
+
+func Generic[E any](e E) uintptr { return caller.PC(0) }
+
+type List[E any] struct{}
+
+func (*List[E]) Push() uintptr { return func() uintptr { return caller.PC(0) }() }
+
+func TestGetFrame_fun(t *testing.T) {
+	methodValue := T{}.Value
+	// the type arguments are `...` since go1.21, and the shape types before
+	// go1.21 起类型参数输出为 `...`, 之前会展开为 shape 类型
+	const typeArgs = `\[(\.\.\.|go\.shape\.int(_0)?)\]`
+	tests := []struct {
+		name        string
+		pc          uintptr
+		wantFun     string // regexp
+		wantRecv    string
+		wantMethod  string
+		wantClosure int
+	}{
+		{"method", T{}.Value(), `T\.Value`, "T", "Value", 0},
+		{"pointer", (&T{}).Pointer(), `\(\*T\)\.Pointer`, "*T", "Pointer", 0},
+		{"method-value", methodValue(), `T\.Value`, "T", "Value", 0},
+		{"generic", Generic(1), `Generic` + typeArgs, "", "Generic", 0},
+		{"generic-method", new(List[int]).Push(), `\(\*List` + typeArgs + `\)\.Push\.func1`, "*List", "Push", 1},
+		{"closure", func() uintptr { return caller.PC(0) }(), `TestGetFrame_fun\.func1`, "", "TestGetFrame_fun", 1},
+		{"nested", func() uintptr {
+			return func() uintptr { return caller.PC(0) }()
+		}(), `TestGetFrame_fun\.func2\.(func)?1`, "", "TestGetFrame_fun", 2}, // func2.1 or func2.func1, depends on go version
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := caller.GetFrame(tt.pc)
+			if f.Pkg != "code.gopub.tech/logs/v2/pkg/caller_test" {
+				t.Errorf("Pkg = %v", f.Pkg)
+			}
+			if !regexp.MustCompile(`^`+tt.wantFun+`$`).MatchString(f.Fun) ||
+				f.Recv != tt.wantRecv || f.Method != tt.wantMethod || f.Closure != tt.wantClosure {
+				t.Errorf("GetFrame() = %q %q %q %d, want %q %q %q %d", f.Fun, f.Recv, f.Method, f.Closure,
+					tt.wantFun, tt.wantRecv, tt.wantMethod, tt.wantClosure)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package caller
+
+import "strings"
+
+// splitFuncName split the full function name reported by the runtime into the package path
+// and the function part, such as
+//
+//	gopkg.in/yaml%2ev3.Unmarshal        -> gopkg.in/yaml.v3, Unmarshal
+//	example.com/x.Map[...].func1        -> example.com/x, Map[...].func1
+//	vendor/golang.org/x/net/http2.Fn    -> golang.org/x/net/http2, Fn
+//
+// the package path ends at the first dot after the last slash (the dots in the last element are
+// escaped as %2e by the linker), the type arguments are not scanned since they may contain slashes and dots.
+//
+// 将运行时给出的完整函数名拆分为包路径和函数部分. 包路径截止到最后一个斜杠之后的第一个点号
+// (链接器会将最后一段中的点号转义为 %2e), 类型参数中可能包含斜杠和点号, 因此不参与查找.
+func splitFuncName(name string) (pkg, fun string) {
+	end := len(name)
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		end = i
+	}
+	start := strings.LastIndexByte(name[:end], '/') + 1
+	i := strings.IndexByte(name[start:end], '.')
+	if i < 0 {
+		return "", name
+	}
+	pkg, fun = strings.ReplaceAll(name[:start+i], "%2e", "."), name[start+i+1:]
+	if i := strings.LastIndex(pkg, "/vendor/"); i >= 0 {
+		pkg = pkg[i+len("/vendor/"):]
+	} else {
+		pkg = strings.TrimPrefix(pkg, "vendor/")
+	}
+	return pkg, fun
+}
+
+// parseFun parse the function part into the receiver type, the method (or function) name and the closure depth,
+// the type arguments and the `-fm` suffix of method value are removed, such as
+//
+//	(*T).Method        -> *T, Method, 0
+//	T[...].Method-fm   -> T, Method, 0
+//	Fn.func1.2         -> "", Fn, 2
+//	init.0             -> "", init, 0
+//
+// 将函数部分解析为接收者类型、方法名(或函数名)及闭包嵌套层数, 类型参数及方法值的 `-fm` 后缀会被移除.
+func parseFun(fun string) (recv, method string, closure int) {
+	parts := splitDot(strings.TrimSuffix(fun, "-fm"))
+	for i := 1; i < len(parts); i++ {
+		if isClosure(parts[i]) {
+			closure = len(parts) - i
+			parts = parts[:i]
+			break
+		}
+	}
+	if len(parts) > 1 && parts[len(parts)-1] == "" { // glob..func1
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 1 && isNumber(parts[len(parts)-1]) { // init.0
+		parts = parts[:len(parts)-1]
+	}
+	switch len(parts) {
+	case 0:
+	case 1:
+		method = parts[0]
+	default:
+		recv = strings.TrimSuffix(strings.TrimPrefix(parts[0], "("), ")")
+		method = parts[1]
+	}
+	return trimTypeArgs(recv), trimTypeArgs(method), closure
+}
+
+// splitDot split the s by dots which are not in brackets or parentheses.
+//
+// 按不在方括号或圆括号内的点号拆分.
+func splitDot(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case '.':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// isClosure reports whether the s is a closure part, such as func1 or 2 (in func1.2),
+// and gowrap1, deferwrap1 generated by go and defer statements.
+//
+// 判断是否为闭包部分, 如 func1 或 2 (func1.2 中), 以及 go, defer 语句生成的 gowrap1, deferwrap1.
+func isClosure(s string) bool {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap"} {
+		if strings.HasPrefix(s, prefix) && isNumber(s[len(prefix):]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// trimTypeArgs remove the type arguments, such as Map[...] -> Map.
+//
+// 移除类型参数, 如 Map[...] -> Map.
+func trimTypeArgs(s string) string {
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,76 @@
+package caller
+
+import "testing"
+
+func Test_splitFuncName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantPkg string
+		wantFun string
+	}{
+		{"main.main", "main", "main"},
+		{"code.gopub.tech/logs.pc", "code.gopub.tech/logs", "pc"},
+		{"code.gopub.tech/logs/pkg/caller.PC", "code.gopub.tech/logs/pkg/caller", "PC"},
+		{"gopkg.in/yaml%2ev3.Unmarshal", "gopkg.in/yaml.v3", "Unmarshal"},
+		{"github.com/a/b.c/d.Fn", "github.com/a/b.c/d", "Fn"},
+		{"example.com/x.Map[...]", "example.com/x", "Map[...]"},
+		{"example.com/x.Map[go.shape.int,example.com/y.T]", "example.com/x", "Map[go.shape.int,example.com/y.T]"},
+		{"example.com/x.(*List[...]).Push", "example.com/x", "(*List[...]).Push"},
+		{"example.com/x.T.Method-fm", "example.com/x", "T.Method-fm"},
+		{"example.com/x.Fn.func1.2", "example.com/x", "Fn.func1.2"},
+		{"vendor/golang.org/x/net/http2/hpack.NewEncoder", "golang.org/x/net/http2/hpack", "NewEncoder"},
+		{"github.com/a/b/vendor/github.com/c/d.Fn", "github.com/c/d", "Fn"},
+		{"runtime.goexit", "runtime", "goexit"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPkg, gotFun := splitFuncName(tt.name)
+			if gotPkg != tt.wantPkg || gotFun != tt.wantFun {
+				t.Errorf("splitFuncName() = %q, %q, want %q, %q", gotPkg, gotFun, tt.wantPkg, tt.wantFun)
+			}
+		})
+	}
+}
+
+func Test_parseFun(t *testing.T) {
+	tests := []struct {
+		fun         string
+		wantRecv    string
+		wantMethod  string
+		wantClosure int
+	}{
+		{"", "", "", 0},
+		{"Fn", "", "Fn", 0},
+		{"T.Method", "T", "Method", 0},
+		{"(*T).Method", "*T", "Method", 0},
+		{"T.Method-fm", "T", "Method", 0},
+		{"(*T).Method-fm", "*T", "Method", 0},
+		{"Fn.func1", "", "Fn", 1},
+		{"Fn.func1.2", "", "Fn", 2},
+		{"Fn.func1.2.3", "", "Fn", 3},
+		{"Fn.func2.func1", "", "Fn", 2},
+		{"(*T).Method.func1", "*T", "Method", 1},
+		{"Fn.gowrap1", "", "Fn", 1},
+		{"Fn.deferwrap1", "", "Fn", 1},
+		{"Map[...]", "", "Map", 0},
+		{"Map[go.shape.int]", "", "Map", 0},
+		{"Map[...].func1", "", "Map", 1},
+		{"(*List[...]).Push", "*List", "Push", 0},
+		{"List[go.shape.struct { A int }].Len", "List", "Len", 0},
+		{"(*List[...]).Push.func1.1", "*List", "Push", 2},
+		{"init.0", "", "init", 0},
+		{"init.func1", "", "init", 1},
+		{"glob..func1", "", "glob", 1},
+		{"function", "", "function", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fun, func(t *testing.T) {
+			gotRecv, gotMethod, gotClosure := parseFun(tt.fun)
+			if gotRecv != tt.wantRecv || gotMethod != tt.wantMethod || gotClosure != tt.wantClosure {
+				t.Errorf("parseFun() = %q, %q, %d, want %q, %q, %d",
+					gotRecv, gotMethod, gotClosure, tt.wantRecv, tt.wantMethod, tt.wantClosure)
+			}
+		})
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"strings"
 	"testing"
 
-	"code.gopub.tech/logs/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/caller"
 )
 
 func TestPC(t *testing.T) {
@@ -1,58 +1,260 @@
 package kv
 
-import "context"
+import (
+	"context"
+	"sync/atomic"
+)
 
 type attrKey struct{}
 
+// attrs is the kvs on ctx, with the sequence number of each pair.
+//
+// ctx 上的键值对, 以及每一对的序号.
+type attrs struct {
+	kvs  []any
+	seqs []uint64 // len(seqs) == len(kvs)/2
+}
+
+// BadKey is the key of a value which has no key, such as the last one of odd-length kvs.
+//
+// 缺少键的值所使用的键, 如奇数个 kvs 的最后一个值.
+const BadKey = "!BADKEY"
+
+var seq uint64
+
+// NextSeq returns an increasing sequence number, it is used to order the kvs added to ctx
+// and the attrs added to a Logger by recency.
+//
+// 返回递增的序号, 用于按添加的先后顺序排列 ctx 及 Logger 上的键值对.
+func NextSeq() uint64 {
+	return atomic.AddUint64(&seq, 1)
+}
+
 // Add add kvs to ctx. Add multi times, the last added kvs is at the first place when Get.
+// the last value of odd-length kvs is added with the key "!BADKEY".
 //
-// 往 ctx 上附加 kv 键值对. 多次添加再 Get, 后添加的会出现在前面.
+// 往 ctx 上附加 kv 键值对. 多次添加再 Get, 后添加的会出现在前面. kvs 为奇数个时, 最后一个值以 "!BADKEY" 为键添加.
 func Add(ctx context.Context, kvs ...any) context.Context {
-	if len(kvs) == 0 || (len(kvs)&1 == 1) { // ignore odd kvs
+	if len(kvs) == 0 {
 		return ctx
 	}
-	pre, _ := ctx.Value(attrKey{}).([]any)
-	return context.WithValue(ctx, attrKey{}, append(kvs, pre...))
+	pre := load(ctx)
+	kvs = fixOdd(kvs)
+	return context.WithValue(ctx, attrKey{}, &attrs{
+		kvs:  append(kvs[:len(kvs):len(kvs)], pre.kvs...),
+		seqs: append(repeat(NextSeq(), len(kvs)/2), pre.seqs...),
+	})
 }
 
 // Get get kvs of this ctx.
 //
 // 获取 ctx 上附加的键值对.
 func Get(ctx context.Context) []any {
-	attrs, _ := ctx.Value(attrKey{}).([]any)
-	return attrs
+	return load(ctx).kvs
+}
+
+// GetSeq get kvs of this ctx, and the sequence number of each pair, see `NextSeq`.
+//
+// 获取 ctx 上附加的键值对, 以及每一对的序号, 参见 `NextSeq`.
+func GetSeq(ctx context.Context) (kvs []any, seqs []uint64) {
+	a := load(ctx)
+	return a.kvs, a.seqs
 }
 
 // Set set kvs to ctx and ignore the previous added.
+// the last value of odd-length kvs is set with the key "!BADKEY".
 //
-// 在 ctx 上设置键值对, 如果之前已经 Add 过会忽略.
+// 在 ctx 上设置键值对, 如果之前已经 Add 过会忽略. kvs 为奇数个时, 最后一个值以 "!BADKEY" 为键设置.
 func Set(ctx context.Context, kvs []any) context.Context {
-	if len(kvs) == 0 || (len(kvs)&1 == 1) { // ignore odd kvs
+	if len(kvs) == 0 {
 		return ctx
 	}
-	return context.WithValue(ctx, attrKey{}, kvs)
+	kvs = fixOdd(kvs)
+	return context.WithValue(ctx, attrKey{}, &attrs{kvs: kvs, seqs: repeat(NextSeq(), len(kvs)/2)})
+}
+
+func load(ctx context.Context) *attrs {
+	if a, ok := ctx.Value(attrKey{}).(*attrs); ok {
+		return a
+	}
+	return &noAttrs
+}
+
+var noAttrs attrs
+
+func repeat(seq uint64, n int) []uint64 {
+	seqs := make([]uint64, n)
+	for i := range seqs {
+		seqs[i] = seq
+	}
+	return seqs
+}
+
+// Lookup returns the value of the key on ctx, the last added one if the key is added more than once.
+//
+// 返回 ctx 上该键的值, 多次添加时返回最后添加的.
+func Lookup(ctx context.Context, key any) (any, bool) {
+	kvs := Get(ctx)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if kvs[i] == key {
+			return kvs[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// Delete returns a ctx without the keys.
+//
+// 返回移除了这些键的 ctx.
+func Delete(ctx context.Context, keys ...any) context.Context {
+	a := load(ctx)
+	result := &attrs{kvs: make([]any, 0, len(a.kvs)), seqs: make([]uint64, 0, len(a.seqs))}
+	for i := 0; i+1 < len(a.kvs); i += 2 {
+		if !contains(keys, a.kvs[i]) {
+			result.kvs = append(result.kvs, a.kvs[i], a.kvs[i+1])
+			result.seqs = append(result.seqs, a.seqs[i/2])
+		}
+	}
+	if len(result.kvs) == len(a.kvs) {
+		return ctx
+	}
+	return context.WithValue(ctx, attrKey{}, result)
+}
+
+// Range calls fn for each key-value pair on ctx until fn returns false,
+// the duplicate keys added before are skipped.
+//
+// 遍历 ctx 上的键值对, 直到 fn 返回 false. 跳过之前添加的重复的键.
+func Range(ctx context.Context, fn func(key, value any) bool) {
+	kvs := Uniq(Get(ctx))
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if !fn(kvs[i], kvs[i+1]) {
+			return
+		}
+	}
+}
+
+// Key is a typed key, the value is stored on ctx with the name as the key, so it is output as an attribute.
+//
+//	var UserID = kv.NewKey[int64]("uid")
+//	ctx = UserID.Add(ctx, 42)
+//	uid, ok := UserID.Get(ctx)
+//
+// 带类型的键, 值以 name 为键存储在 ctx 上, 因此会作为键值对输出.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey create a typed key with the name.
+//
+// 创建一个带类型的键.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+func (k Key[T]) Name() string { return k.name }
+
+// Add add the value to ctx, see `Add`.
+//
+// 往 ctx 上附加该键的值, 参见 `Add`.
+func (k Key[T]) Add(ctx context.Context, value T) context.Context {
+	return Add(ctx, k.name, value)
+}
+
+// Get returns the value of the key on ctx, false if not found or the value is not a T.
+//
+// 返回 ctx 上该键的值, 不存在或值的类型不是 T 时返回 false.
+func (k Key[T]) Get(ctx context.Context) (T, bool) {
+	v, ok := Lookup(ctx, k.name)
+	t, ok2 := v.(T)
+	return t, ok && ok2
+}
+
+// fixOdd add the "!BADKEY" key for the last value of odd-length kvs.
+//
+// 为奇数个 kvs 的最后一个值添加 "!BADKEY" 键.
+func fixOdd(kvs []any) []any {
+	if len(kvs)&1 == 0 {
+		return kvs
+	}
+	n := len(kvs) - 1
+	return append(kvs[:n:n], BadKey, kvs[n])
+}
+
+func contains(keys []any, key any) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
 }
 
 // Uniq filter the duplicate key of kvs.
 // if a key apperance more than once, only the first value will retain.
+// the last value of odd-length kvs is retained with the key "!BADKEY".
+// the even-length kvs is returned as is if there is no duplicate key,
+// and no allocation is needed if it has at most 16 pairs.
 //
-// 过滤 kvs 中重复的 key. 保留第一次出现的 key-value
+// 过滤 kvs 中重复的 key. 保留第一次出现的 key-value. 长度为偶数且没有重复的 key 时直接返回 kvs,
+// 不超过 16 对时无需分配内存.
 //
 // [key1, first, key1, second] => [key1, first]
 func Uniq(kvs []any) []any {
-	if len(kvs) == 0 || (len(kvs)&1 == 1) { // ignore odd kvs
+	if len(kvs) == 0 {
 		return nil
 	}
+	kvs = fixOdd(kvs)
 	count := len(kvs)
-	result := make([]any, 0, count)
-	m := make(map[any]struct{}, count)
+	if count <= smallN*2 {
+		return uniqSmall(kvs)
+	}
+	var result []any
+	m := make(map[any]struct{}, count/2)
 	for i := 0; i < count; i = i + 2 {
 		key := kvs[i]
-		val := kvs[i+1]
-		if _, ok := m[key]; !ok {
-			result = append(result, key, val)
-			m[key] = struct{}{}
+		_, dup := m[key]
+		switch {
+		case dup && result == nil: // 第一个重复的 key, 复制之前的键值对
+			result = append(make([]any, 0, count-2), kvs[:i]...)
+		case !dup && result != nil:
+			result = append(result, key, kvs[i+1])
 		}
+		m[key] = struct{}{}
+	}
+	if result == nil {
+		return kvs
+	}
+	return result
+}
+
+// smallN is the max number of pairs which are deduplicated without a map.
+//
+// 不使用 map 去重的最大键值对数量.
+const smallN = 16
+
+// uniqSmall deduplicate the kvs by comparing each pair, it's faster than the map when n is small.
+//
+// 通过逐对比较去重, n 较小时比 map 更快.
+func uniqSmall(kvs []any) []any {
+	var result []any
+	for i := 0; i < len(kvs); i += 2 {
+		dup := false
+		for j := 0; j < i; j += 2 {
+			if kvs[j] == kvs[i] {
+				dup = true
+				break
+			}
+		}
+		switch {
+		case dup && result == nil: // 第一个重复的 key, 复制之前的键值对
+			result = append(make([]any, 0, len(kvs)-2), kvs[:i]...)
+		case !dup && result != nil:
+			result = append(result, kvs[i], kvs[i+1])
+		}
+	}
+	if result == nil {
+		return kvs
 	}
 	return result
 }
@@ -5,19 +5,22 @@ import (
 	"reflect"
 	"testing"
 
-	"code.gopub.tech/logs/pkg/kv"
+	"code.gopub.tech/logs/v2/pkg/kv"
 )
 
 var ctx = context.Background()
 
 func TestKV(t *testing.T) {
-	ctx = kv.Add(ctx, "key")        // nothing happend since no value
-	ctx = kv.Set(ctx, []any{"key"}) // nothing happend since no value
-	if !reflect.DeepEqual(kv.Uniq([]any{"key"}), []any(nil)) {
+	odd := kv.Add(ctx, "key")             // odd kvs is added with !BADKEY
+	odd = kv.Set(odd, []any{"a", 1, "b"}) // odd kvs is set with !BADKEY
+	if !reflect.DeepEqual(kv.Get(odd), []any{"a", 1, kv.BadKey, "b"}) {
+		t.Errorf("Fail: %#v", kv.Get(odd))
+	}
+	if !reflect.DeepEqual(kv.Uniq([]any{"key"}), []any{kv.BadKey, "key"}) {
 		t.Errorf("Fail: %#v", kv.Uniq([]any{"key"}))
 	}
 
-	ctx = kv.Add(ctx, "key", "value")
+	ctx := kv.Add(ctx, "key", "value")
 	if !reflect.DeepEqual(kv.Get(ctx), []any{"key", "value"}) {
 		t.Errorf("Fail")
 	}
@@ -30,3 +33,121 @@ func TestKV(t *testing.T) {
 		t.Errorf("Fail")
 	}
 }
+
+func TestLookup(t *testing.T) {
+	ctx := kv.Add(kv.Add(ctx, "a", 1, "b", 2), "a", 3)
+	if v, ok := kv.Lookup(ctx, "a"); !ok || v != 3 {
+		t.Errorf("Lookup(a) = %v, %v", v, ok)
+	}
+	if v, ok := kv.Lookup(ctx, "c"); ok || v != nil {
+		t.Errorf("Lookup(c) = %v, %v", v, ok)
+	}
+	del := kv.Delete(ctx, "a", "c")
+	if !reflect.DeepEqual(kv.Get(del), []any{"b", 2}) {
+		t.Errorf("Delete() = %v", kv.Get(del))
+	}
+	if kv.Delete(ctx, "c") != ctx {
+		t.Errorf("Delete() should return the ctx if nothing deleted")
+	}
+	var got []any
+	kv.Range(ctx, func(key, value any) bool {
+		got = append(got, key, value)
+		return true
+	})
+	if !reflect.DeepEqual(got, []any{"a", 3, "b", 2}) {
+		t.Errorf("Range() = %v", got)
+	}
+	got = nil
+	kv.Range(ctx, func(key, value any) bool {
+		got = append(got, key, value)
+		return false
+	})
+	if !reflect.DeepEqual(got, []any{"a", 3}) {
+		t.Errorf("Range() stop = %v", got)
+	}
+}
+
+func TestGetSeq(t *testing.T) {
+	ctx := kv.Add(ctx, "a", 1, "b", 2)
+	ctx = kv.Add(ctx, "c", 3)
+	kvs, seqs := kv.GetSeq(ctx)
+	if !reflect.DeepEqual(kvs, []any{"c", 3, "a", 1, "b", 2}) || len(seqs) != 3 ||
+		seqs[0] <= seqs[1] || seqs[1] != seqs[2] {
+		t.Errorf("GetSeq() = %v, %v", kvs, seqs)
+	}
+	kvs, seqs = kv.GetSeq(kv.Delete(ctx, "a"))
+	if !reflect.DeepEqual(kvs, []any{"c", 3, "b", 2}) || len(seqs) != 2 || seqs[0] <= seqs[1] {
+		t.Errorf("GetSeq() after Delete = %v, %v", kvs, seqs)
+	}
+	if seq := kv.NextSeq(); seq <= seqs[0] {
+		t.Errorf("NextSeq() = %v, should be greater than %v", seq, seqs[0])
+	}
+}
+
+func TestKey(t *testing.T) {
+	uid := kv.NewKey[int64]("uid")
+	if v, ok := uid.Get(ctx); ok || v != 0 {
+		t.Errorf("Get() = %v, %v", v, ok)
+	}
+	ctx := uid.Add(ctx, 42)
+	if v, ok := uid.Get(ctx); !ok || v != 42 {
+		t.Errorf("Get() = %v, %v", v, ok)
+	}
+	if v, ok := kv.NewKey[string]("uid").Get(ctx); ok || v != "" {
+		t.Errorf("Get() with wrong type = %v, %v", v, ok)
+	}
+	if !reflect.DeepEqual(kv.Get(ctx), []any{uid.Name(), int64(42)}) {
+		t.Errorf("Get() = %v", kv.Get(ctx))
+	}
+}
+
+func TestUniq(t *testing.T) {
+	large := make([]any, 0, 40)
+	largeUniq := make([]any, 0, 40)
+	for i := 0; i < 20; i++ {
+		large = append(large, i%10, i)
+		largeUniq = append(largeUniq, i, i)
+	}
+	tests := []struct {
+		name string
+		kvs  []any
+		want []any
+	}{
+		{"empty", nil, nil},
+		{"odd", []any{"k"}, []any{kv.BadKey, "k"}},
+		{"odd-dup", []any{"k", 1, "k"}, []any{"k", 1, kv.BadKey, "k"}},
+		{"uniq", []any{"a", 1, "b", 2}, []any{"a", 1, "b", 2}},
+		{"dup", []any{"a", 1, "b", 2, "a", 3, "c", 4, "b", 5}, []any{"a", 1, "b", 2, "c", 4}},
+		{"large", large, large[:20]},
+		{"large-uniq", largeUniq, largeUniq},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kv.Uniq(tt.kvs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Uniq() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	for _, kvs := range [][]any{{"a", 1, "b", 2}, largeUniq} {
+		if got := kv.Uniq(kvs); &got[0] != &kvs[0] {
+			t.Errorf("Uniq() should return the kvs as is without duplicate keys: %v", kvs)
+		}
+	}
+}
+
+func BenchmarkUniq(b *testing.B) {
+	uniq := []any{"a", 1, "b", 2, "c", 3, "d", 4}
+	dup := []any{"a", 1, "b", 2, "c", 3, "a", 4}
+	b.Run("uniq", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			kv.Uniq(uniq)
+		}
+	})
+	b.Run("dup", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			kv.Uniq(dup)
+		}
+	})
+}
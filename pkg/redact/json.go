@@ -0,0 +1,304 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TagName is the struct tag which marks a field as sensitive: `log:"redact"`.
+//
+// 标记敏感字段的结构体标签: `log:"redact"`.
+const TagName = "log"
+
+// Marshal is like json.Marshal, but the struct fields tagged `log:"redact"` are masked.
+//
+// 与 json.Marshal 类似, 但带有 `log:"redact"` 标签的结构体字段会被脱敏.
+func Marshal(v any) ([]byte, error) { return json.Marshal(Struct(v)) }
+
+// MarshalIndent is like Marshal but indented, see json.MarshalIndent.
+//
+// 与 Marshal 类似, 但输出是缩进的, 参见 json.MarshalIndent.
+func MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	return json.MarshalIndent(Struct(v), prefix, indent)
+}
+
+// Struct returns a value which is marshalled to json with the tagged fields masked.
+// v is returned as is if its type has no tagged fields, so the common values cost nothing.
+// the struct fields are output by the rules of encoding/json: the json tag name, `-`, omitempty and string option,
+// and the fields of the embedded structs are promoted, a tagged embedded struct is masked as a whole.
+//
+// 返回一个 json 序列化时会对带标签字段脱敏的值. 如果类型中没有带标签的字段, 则直接返回 v, 常见的值没有额外开销.
+// 结构体字段按 encoding/json 的规则输出: json 标签名, `-`, omitempty 及 string 选项, 嵌入结构体的字段会被提升, 带标签的嵌入结构体整体脱敏.
+func Struct(v any) any {
+	switch v.(type) {
+	case nil, string, bool, int, int64, uint64, float64, []byte, json.Marshaler:
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	if !needRedact(rv.Type()) {
+		return v
+	}
+	return convert(rv, 0)
+}
+
+// maxDepth is the max nesting level of the values to redact, it stops the cyclic pointers.
+//
+// 脱敏时值的最大嵌套层数, 用于终止循环引用的指针.
+const maxDepth = 64
+
+var (
+	typeCache     sync.Map // reflect.Type -> bool
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// needRedact reports whether the type may have tagged fields, an interface may hold any value, so it needs too.
+//
+// 返回该类型是否可能包含带标签的字段. 接口可能持有任意值, 因此也需要.
+func needRedact(t reflect.Type) bool {
+	if v, ok := typeCache.Load(t); ok {
+		return v.(bool)
+	}
+	need := scan(t, map[reflect.Type]bool{})
+	typeCache.Store(t, need)
+	return need
+}
+
+func scan(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] { // 递归类型: 由其他字段决定
+		return false
+	}
+	seen[t] = true
+	if t.Implements(marshalerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return scan(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() && !f.Anonymous {
+				continue
+			}
+			if tagged(f) || scan(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func tagged(f reflect.StructField) bool {
+	for _, opt := range strings.Split(f.Tag.Get(TagName), ",") {
+		if opt == "redact" {
+			return true
+		}
+	}
+	return false
+}
+
+// convert returns a copy of the value for json marshalling, the tagged fields are replaced with the mask.
+//
+// 返回值的用于 json 序列化的副本, 带标签的字段会被替换为掩码.
+func convert(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxDepth {
+		return errValue{}
+	}
+	if !needRedact(v.Type()) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return convert(v.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		result := make([]any, v.Len())
+		for i := range result {
+			result[i] = convert(v.Index(i), depth+1)
+		}
+		return result
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		result := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), anyType), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value := convert(iter.Value(), depth+1)
+			result.SetMapIndex(iter.Key(), reflect.ValueOf(&value).Elem())
+		}
+		return result.Interface()
+	case reflect.Struct:
+		var obj object
+		obj.addFields(v, depth, 0)
+		return obj
+	}
+	return v.Interface()
+}
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// object is a json object whose fields are output in order.
+//
+// 按顺序输出字段的 json 对象.
+type object struct {
+	fields []field
+}
+
+type field struct {
+	name  string
+	value any
+	depth int // depth of the embedded struct, the shallower one wins 嵌入结构体的层数, 浅的优先
+}
+
+func (o *object) addFields(v reflect.Value, depth, embed int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if f.Anonymous && name == "" && !tagged(f) { // 带标签的嵌入结构体整体脱敏, 不提升其字段
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				if !f.IsExported() { // 与 encoding/json 一致, 忽略未导出的嵌入结构体指针
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct { // 提升嵌入结构体的字段
+				if fv.Kind() == reflect.Pointer {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				o.addFields(fv, depth, embed+1)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if hasOpt(opts, "omitempty") && isEmpty(fv) {
+			continue
+		}
+		var value any
+		switch {
+		case tagged(f):
+			value = Mask
+		case hasOpt(opts, "string") && isScalar(fv.Kind()):
+			b, err := json.Marshal(fv.Interface())
+			if err != nil {
+				value = errValue{err: err}
+			} else {
+				value = string(b)
+			}
+		default:
+			value = convert(fv, depth+1)
+		}
+		o.add(field{name: name, value: value, depth: embed})
+	}
+}
+
+func (o *object) add(f field) {
+	for i, exist := range o.fields {
+		if exist.name == f.name {
+			if f.depth < exist.depth {
+				o.fields[i] = f
+			}
+			return
+		}
+	}
+	o.fields = append(o.fields, f)
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func hasOpt(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty is the empty value of the omitempty option, see encoding/json.
+//
+// omitempty 选项的空值, 参见 encoding/json.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+func isScalar(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+var errTooDeep = errors.New("redact: exceeded max depth")
+
+// errValue fails the json marshalling, so the value is not output without redaction.
+//
+// 使 json 序列化失败, 以免输出未脱敏的值.
+type errValue struct{ err error }
+
+func (e errValue) MarshalJSON() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return nil, errTooDeep
+}
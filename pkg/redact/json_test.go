@@ -0,0 +1,137 @@
+package redact_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/redact"
+)
+
+type Base struct {
+	ID     int    `json:"id"`
+	Secret string `log:"redact"`
+}
+
+type inner struct {
+	Hidden string
+	Key    string `log:"redact"`
+}
+
+type User struct {
+	Base
+	inner
+	Name     string                  `json:"name"`
+	Password string                  `json:"password,omitempty" log:"redact"`
+	Empty    string                  `json:",omitempty"`
+	Ignored  string                  `json:"-"`
+	Count    int                     `json:"count,string"`
+	Friends  []*User                 `json:"friends,omitempty"`
+	Tags     map[string]any          `json:"tags,omitempty"`
+	Token    redact.Redacted[string] `json:"token"`
+	private  string
+}
+
+type Node struct {
+	Next *Node
+	Key  string `log:"redact"`
+}
+
+func TestStruct(t *testing.T) {
+	user := User{
+		Base:     Base{ID: 1, Secret: "s1"},
+		inner:    inner{Hidden: "h", Key: "k"},
+		Name:     "alice",
+		Password: "p1",
+		Ignored:  "i",
+		Count:    2,
+		Friends:  []*User{{Name: "bob", Password: "p2"}},
+		Tags:     map[string]any{"base": Base{Secret: "s2"}},
+		Token:    redact.New("t"),
+		private:  "x",
+	}
+	for _, tCase := range []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"nil", nil, `null`},
+		{"string", "password", `"password"`},
+		{"untagged", struct{ A int }{1}, `{"A":1}`},
+		{"base", Base{ID: 1, Secret: "s"}, `{"id":1,"Secret":"******"}`},
+		{"pointer", &Base{ID: 1, Secret: "s"}, `{"id":1,"Secret":"******"}`},
+		{"nil-pointer", (*Base)(nil), `null`},
+		{"slice", []Base{{Secret: "s"}}, `[{"id":0,"Secret":"******"}]`},
+		{"map", map[string]Base{"a": {Secret: "s"}}, `{"a":{"id":0,"Secret":"******"}}`},
+		{"interface", []any{1, Base{Secret: "s"}}, `[1,{"id":0,"Secret":"******"}]`},
+		{"user", user, `{"id":1,"Secret":"******","Hidden":"h","Key":"******","name":"alice","password":"******","count":"2",` +
+			`"friends":[{"id":0,"Secret":"******","Hidden":"","Key":"******","name":"bob","password":"******","count":"0","token":"******"}],` +
+			`"tags":{"base":{"id":0,"Secret":"******"}},"token":"******"}`},
+	} {
+		t.Run(tCase.name, func(t *testing.T) {
+			got, err := redact.Marshal(tCase.v)
+			if err != nil || string(got) != tCase.want {
+				t.Errorf("got= %s, err=%v\nwant= %s", got, err, tCase.want)
+			}
+		})
+	}
+
+	node := &Node{Key: "k"}
+	node.Next = node
+	if _, err := redact.Marshal(node); err == nil {
+		t.Errorf("cyclic value should fail")
+	}
+	b, err := redact.MarshalIndent(Base{ID: 1}, "", " ")
+	if err != nil || string(b) != "{\n \"id\": 1,\n \"Secret\": \"******\"\n}" {
+		t.Errorf("MarshalIndent() = %s, %v", b, err)
+	}
+	if b, _ := json.Marshal(redact.Struct(Base{})); string(b) != `{"id":0,"Secret":"******"}` {
+		t.Errorf("Struct() = %s", b)
+	}
+}
+
+func BenchmarkStruct(b *testing.B) {
+	b.Run("untagged", func(b *testing.B) {
+		v := struct{ A, B int }{1, 2}
+		for i := 0; i < b.N; i++ {
+			_, _ = redact.Marshal(v)
+		}
+	})
+	b.Run("tagged", func(b *testing.B) {
+		v := Base{ID: 1, Secret: "s"}
+		for i := 0; i < b.N; i++ {
+			_, _ = redact.Marshal(v)
+		}
+	})
+}
+
+func TestStruct_embeddedPointer(t *testing.T) {
+	type Outer struct {
+		*Base
+		*inner // 与 encoding/json 一致, 忽略未导出的嵌入结构体指针
+		A      int
+	}
+	got, err := redact.Marshal(Outer{Base: &Base{ID: 1, Secret: "s"}, inner: &inner{Hidden: "h"}, A: 2})
+	if want := `{"id":1,"Secret":"******","A":2}`; err != nil || string(got) != want {
+		t.Errorf("got= %s, err=%v want= %s", got, err, want)
+	}
+	got, err = redact.Marshal(Outer{})
+	if want := `{"A":0}`; err != nil || string(got) != want {
+		t.Errorf("got= %s, err=%v want= %s", got, err, want)
+	}
+}
+
+func TestStruct_taggedEmbedded(t *testing.T) {
+	type Credentials struct {
+		User string
+		Pass string
+	}
+	type Outer struct {
+		Credentials `log:"redact"`
+		*Base       `log:"redact"`
+		A           int
+	}
+	got, err := redact.Marshal(Outer{Credentials: Credentials{User: "u", Pass: "p"}, Base: &Base{ID: 1}, A: 2})
+	if want := `{"Credentials":"******","Base":"******","A":2}`; err != nil || string(got) != want {
+		t.Errorf("got= %s, err=%v want= %s", got, err, want)
+	}
+}
@@ -0,0 +1,51 @@
+package redact
+
+import (
+	"fmt"
+	"io"
+)
+
+// Mask is the text output in place of a redacted value.
+//
+// 脱敏后替代原值输出的文本.
+const Mask = "******"
+
+// DefaultKeys is a list of common key patterns of sensitive attrs, `*` matches any characters.
+//
+// 常见的敏感键的模式, `*` 匹配任意字符.
+var DefaultKeys = []string{
+	"password", "passwd", "pwd", "*secret*", "*token*", "authorization", "cookie", "set-cookie", "*api_key*", "*apikey*",
+}
+
+// Redacted wraps a value which should never be output, it is output as `******` in text and json.
+// it can be used as a log arg, an attr value or the type of a struct field.
+//
+//	logs.InfoKV(ctx, "login", "user", name, "token", redact.New(token))
+//
+// 包装不应输出的值, 在文本及 json 中均输出为 `******`. 可用作日志参数, 键值对的值, 或结构体字段的类型.
+type Redacted[T any] struct {
+	value T
+}
+
+// New wraps the value as Redacted.
+//
+// 将值包装为 Redacted.
+func New[T any](value T) Redacted[T] { return Redacted[T]{value: value} }
+
+// Get returns the wrapped value.
+//
+// 返回被包装的值.
+func (r Redacted[T]) Get() T { return r.value }
+
+func (r Redacted[T]) String() string { return Mask }
+
+func (r Redacted[T]) GoString() string { return Mask }
+
+// Format implements fmt.Formatter, all verbs print the mask.
+//
+// 实现 fmt.Formatter, 所有动词均输出掩码.
+func (r Redacted[T]) Format(f fmt.State, verb rune) { _, _ = io.WriteString(f, Mask) }
+
+func (r Redacted[T]) MarshalJSON() ([]byte, error) { return []byte(`"` + Mask + `"`), nil }
+
+func (r Redacted[T]) MarshalText() ([]byte, error) { return []byte(Mask), nil }
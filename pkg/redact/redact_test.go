@@ -0,0 +1,29 @@
+package redact_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/redact"
+)
+
+func TestRedacted(t *testing.T) {
+	token := redact.New("secret-token")
+	if token.Get() != "secret-token" {
+		t.Errorf("Get() = %q", token.Get())
+	}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d"} {
+		if got := fmt.Sprintf(format, token); got != redact.Mask {
+			t.Errorf("%s: got= %q", format, got)
+		}
+	}
+	b, err := json.Marshal(map[string]any{"token": token, "ptr": &token})
+	if err != nil || string(b) != `{"ptr":"******","token":"******"}` {
+		t.Errorf("json.Marshal() = %s, %v", b, err)
+	}
+	b, err = json.Marshal(map[redact.Redacted[string]]int{token: 1})
+	if err != nil || string(b) != `{"******":1}` {
+		t.Errorf("json.Marshal() map key = %s, %v", b, err)
+	}
+}
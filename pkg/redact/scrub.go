@@ -0,0 +1,61 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Scrubber masks the sensitive fragments of a text, such as the log message.
+//
+// 对文本(如日志消息)中的敏感片段脱敏.
+type Scrubber func(s string) string
+
+// Regexp returns a Scrubber which replaces the matches of the re with the mask.
+//
+// 返回将正则匹配的内容替换为掩码的 Scrubber.
+func Regexp(re *regexp.Regexp, mask string) Scrubber {
+	return func(s string) string { return re.ReplaceAllLiteralString(s, mask) }
+}
+
+var (
+	emailRegexp      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	creditCardRegexp = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// Email masks the email addresses.
+//
+// 对邮箱地址脱敏.
+func Email(s string) string { return emailRegexp.ReplaceAllLiteralString(s, Mask) }
+
+// CreditCard masks the credit card numbers except the last 4 digits, such as `************1111`.
+// a number of 13-19 digits (may be separated by spaces or dashes) is masked only if it passes the Luhn check.
+//
+// 对信用卡号脱敏, 保留后 4 位, 如 `************1111`.
+// 13-19 位数字(可以用空格或短横线分隔)只有通过 Luhn 校验时才会被脱敏.
+func CreditCard(s string) string {
+	return creditCardRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		digits := strings.NewReplacer(" ", "", "-", "").Replace(match)
+		if !luhn(digits) {
+			return match
+		}
+		return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+	})
+}
+
+// luhn reports whether the digits pass the Luhn check.
+//
+// 返回数字是否通过 Luhn 校验.
+func luhn(digits string) bool {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,30 @@
+package redact_test
+
+import (
+	"regexp"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/redact"
+)
+
+func TestScrubber(t *testing.T) {
+	for _, tCase := range []struct {
+		name  string
+		scrub redact.Scrubber
+		in    string
+		want  string
+	}{
+		{"email", redact.Email, "mail to alice.b+1@example.com now", "mail to ****** now"},
+		{"card", redact.CreditCard, "card 4111111111111111 paid", "card ************1111 paid"},
+		{"card-separated", redact.CreditCard, "card 4111-1111-1111-1111, 5500 0000 0000 0004.", "card ************1111, ************0004."},
+		{"not-luhn", redact.CreditCard, "order 4111111111111112", "order 4111111111111112"},
+		{"short", redact.CreditCard, "id 411111111111", "id 411111111111"},
+		{"regexp", redact.Regexp(regexp.MustCompile(`sk-[0-9a-z]+`), "sk-***"), "key=sk-abc123", "key=sk-***"},
+	} {
+		t.Run(tCase.name, func(t *testing.T) {
+			if got := tCase.scrub(tCase.in); got != tCase.want {
+				t.Errorf("got= %q want = %q", got, tCase.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,15 @@
 package trie
 
+import "strings"
+
+// Wildcard matches exactly one segment in a segment-aware Tree.
+//
+// 通配符, 在按段匹配的前缀树中匹配任意一段.
+const Wildcard = "*"
+
 type Tree[T any] struct {
-	root *node[T]
+	root    *node[T]
+	segment bool // match on `/` and `.` boundaries 按段匹配
 }
 
 //	root=5
@@ -17,8 +25,10 @@ type Tree[T any] struct {
 // github   -> 20
 // github/a -> 30
 type node[T any] struct {
-	data *T // 为了下方需要与 nil 比较 所以取地址
-	chd  map[rune]*node[T]
+	key  string              // the inserted path 插入时的路径
+	data *T                  // 为了下方需要与 nil 比较 所以取地址
+	chd  map[rune]*node[T]   // children by rune       按字符索引的子节点
+	seg  map[string]*node[T] // children by segment   按段索引的子节点
 }
 
 func NewTree[T any](rootData T) *Tree[T] {
@@ -28,49 +38,186 @@ func NewTree[T any](rootData T) *Tree[T] {
 	}}
 }
 
+// NewSegmentTree create a segment-aware Tree, which matches prefix only on `/` and `.` boundaries,
+// so "github.com/acme/db" matches "github.com/acme/db/sql" but not "github.com/acme/dbmigrate".
+// A `*` segment matches any one segment, such as "github.com/acme/*/internal".
+//
+// 创建按段匹配的前缀树, 仅在 `/` 和 `.` 边界处匹配前缀,
+// 因此 "github.com/acme/db" 能匹配 "github.com/acme/db/sql" 但不能匹配 "github.com/acme/dbmigrate".
+// 段 `*` 可匹配任意一段, 如 "github.com/acme/*/internal".
+func NewSegmentTree[T any](rootData T) *Tree[T] {
+	return &Tree[T]{root: &node[T]{
+		data: &rootData,
+		seg:  make(map[string]*node[T]),
+	}, segment: true}
+}
+
 func (t *Tree[T]) Insert(path string, data T) *Tree[T] {
 	n := t.root
-	for _, r := range path {
-		chd, ok := n.chd[r]
-		if !ok {
-			chd = &node[T]{chd: make(map[rune]*node[T])}
-			n.chd[r] = chd
+	if t.segment {
+		for _, s := range split(path) {
+			chd, ok := n.seg[s]
+			if !ok {
+				chd = &node[T]{seg: make(map[string]*node[T])}
+				n.seg[s] = chd
+			}
+			n = chd
+		}
+	} else {
+		for _, r := range path {
+			chd, ok := n.chd[r]
+			if !ok {
+				chd = &node[T]{chd: make(map[rune]*node[T])}
+				n.chd[r] = chd
+			}
+			n = chd
 		}
-		n = chd
 	}
+	n.key = path
 	n.data = &data
 	return t
 }
 
+// Delete remove the data of the path, returns false if the path is not inserted.
+//
+// 删除指定路径的数据, 如果该路径未插入过则返回 false.
+func (t *Tree[T]) Delete(path string) bool {
+	var (
+		nodes = []*node[T]{t.root} // 沿途经过的节点
+		segs  []string
+		runes []rune
+		n     = t.root
+	)
+	if t.segment {
+		segs = split(path)
+		for _, s := range segs {
+			if n = n.seg[s]; n == nil {
+				return false
+			}
+			nodes = append(nodes, n)
+		}
+	} else {
+		runes = []rune(path)
+		for _, r := range runes {
+			if n = n.chd[r]; n == nil {
+				return false
+			}
+			nodes = append(nodes, n)
+		}
+	}
+	if n.data == nil {
+		return false
+	}
+	n.data = nil
+	// 自下而上删除没有数据也没有子节点的节点
+	for i := len(nodes) - 1; i > 0; i-- {
+		if n := nodes[i]; n.data != nil || len(n.chd) > 0 || len(n.seg) > 0 {
+			break
+		}
+		if t.segment {
+			delete(nodes[i-1].seg, segs[i-1])
+		} else {
+			delete(nodes[i-1].chd, runes[i-1])
+		}
+	}
+	return true
+}
+
 func (t *Tree[T]) Search(path string) (result T) {
+	result, _ = t.SearchWithKey(path)
+	return result
+}
+
+// SearchWithKey is like Search, but also returns the inserted path which matched.
+//
+// 与 Search 类似, 但同时返回匹配到的插入路径.
+func (t *Tree[T]) SearchWithKey(path string) (result T, key string) {
+	if t.segment {
+		if n, _ := searchSegment(t.root, split(path), 0); n != nil {
+			return *n.data, n.key
+		}
+		return result, ""
+	}
 	currentNode := t.root
 	if currentNode.data != nil {
 		result = *currentNode.data // 以跟节点结果为兜底
+		key = currentNode.key
 	}
 	for _, r := range path { // 向下搜索前缀树
 		if chd, ok := currentNode.chd[r]; ok { // 如果有这个节点
 			currentNode = chd // 更新当前指向 以便继续向下搜索
 			if currentNode.data != nil {
 				result = *currentNode.data // 更新当前值
+				key = currentNode.key
 			}
 		} else {
 			break // 没有了 就用最靠近的前缀结果
 		}
 	}
-	return result
+	return result, key
+}
+
+// searchSegment returns the deepest node which has data, and its depth.
+// exact segment is preferred to wildcard when they have the same depth.
+//
+// 返回有数据的最深节点及其深度. 深度相同时优先精确匹配而非通配符.
+func searchSegment[T any](n *node[T], segs []string, depth int) (found *node[T], foundDepth int) {
+	if n.data != nil {
+		found, foundDepth = n, depth
+	}
+	if len(segs) == 0 {
+		return found, foundDepth
+	}
+	s := segs[0]
+	if chd, ok := n.seg[s]; ok {
+		if f, d := searchSegment(chd, segs[1:], depth+1); f != nil && d > foundDepth {
+			found, foundDepth = f, d
+		}
+	}
+	if !isSeparator(s) {
+		if chd, ok := n.seg[Wildcard]; ok {
+			if f, d := searchSegment(chd, segs[1:], depth+1); f != nil && d > foundDepth {
+				found, foundDepth = f, d
+			}
+		}
+	}
+	return found, foundDepth
+}
+
+// split the path into segments and separators.
+//
+// 将路径拆分为段和分隔符. "github.com/a" -> ["github", ".", "com", "/", "a"]
+func split(path string) (segs []string) {
+	for len(path) > 0 {
+		i := strings.IndexAny(path, "/.")
+		switch {
+		case i < 0:
+			return append(segs, path)
+		case i > 0:
+			segs = append(segs, path[:i])
+		}
+		segs = append(segs, path[i:i+1])
+		path = path[i+1:]
+	}
+	return segs
 }
 
+func isSeparator(s string) bool { return s == "/" || s == "." }
+
 func (t *Tree[T]) ToMap() map[string]T {
 	result := make(map[string]T)
-	dump("", t.root, result)
+	dump(t.root, result)
 	return result
 }
 
-func dump[T any](path string, n *node[T], result map[string]T) {
+func dump[T any](n *node[T], result map[string]T) {
 	if n.data != nil {
-		result[path] = *n.data
+		result[n.key] = *n.data
+	}
+	for _, chd := range n.chd {
+		dump(chd, result)
 	}
-	for r, chd := range n.chd {
-		dump(path+string(r), chd, result)
+	for _, chd := range n.seg {
+		dump(chd, result)
 	}
 }
@@ -4,7 +4,7 @@ import (
 	"reflect"
 	"testing"
 
-	"code.gopub.tech/logs/pkg/trie"
+	"code.gopub.tech/logs/v2/pkg/trie"
 )
 
 func TestTrie(t *testing.T) {
@@ -56,3 +56,72 @@ func TestTrie(t *testing.T) {
 		}
 	}
 }
+
+func TestSegmentTree(t *testing.T) {
+	tree := trie.NewSegmentTree(10)
+	tree.Insert("github.com/acme/db", 20)
+	tree.Insert("github.com/acme/*/internal", 30)
+	tree.Insert("github.com/acme/api/internal", 40)
+	tree.Insert("main", 50)
+	for _, tCase := range []struct {
+		path    string
+		want    int
+		wantKey string
+	}{
+		{"", 10, ""},
+		{"github.com/acme", 10, ""},
+		{"github.com/acme/db", 20, "github.com/acme/db"},
+		{"github.com/acme/db/", 20, "github.com/acme/db"},
+		{"github.com/acme/db/sql", 20, "github.com/acme/db"},
+		{"github.com/acme/dbmigrate", 10, ""},
+		{"github.com/acme/db/internal", 30, "github.com/acme/*/internal"},
+		{"github.com/acme/web/internal/x", 30, "github.com/acme/*/internal"},
+		{"github.com/acme/web/internals", 10, ""},
+		{"github.com/acme/api/internal", 40, "github.com/acme/api/internal"},
+		{"main", 50, "main"},
+		{"main.main", 50, "main"},
+		{"mainx", 10, ""},
+	} {
+		got, key := tree.SearchWithKey(tCase.path)
+		if got != tCase.want || key != tCase.wantKey {
+			t.Errorf("path=%v got=%v,%q want=%v,%q", tCase.path, got, key, tCase.want, tCase.wantKey)
+		}
+	}
+	if !reflect.DeepEqual(tree.ToMap(), map[string]int{
+		"":                             10,
+		"github.com/acme/db":           20,
+		"github.com/acme/*/internal":   30,
+		"github.com/acme/api/internal": 40,
+		"main":                         50,
+	}) {
+		t.Errorf("ToMap fail: %v", tree.ToMap())
+	}
+	if tree.Delete("github.com/acme") {
+		t.Errorf("Delete not inserted path should return false")
+	}
+	if !tree.Delete("github.com/acme/api/internal") {
+		t.Errorf("Delete fail")
+	}
+	if got := tree.Search("github.com/acme/api/internal"); got != 30 {
+		t.Errorf("got=%v want=30 after delete", got)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tree := trie.NewTree(10).Insert("gitee.com", 20).Insert("gitee.com/pub-go", 30)
+	if tree.Delete("gitee") || tree.Delete("gitee.com/pub") {
+		t.Errorf("Delete not inserted path should return false")
+	}
+	if !tree.Delete("gitee.com/pub-go") {
+		t.Errorf("Delete fail")
+	}
+	if tree.Delete("gitee.com/pub-go") {
+		t.Errorf("Delete twice should return false")
+	}
+	if got, key := tree.SearchWithKey("gitee.com/pub-go"); got != 20 || key != "gitee.com" {
+		t.Errorf("got=%v,%q want=20,gitee.com", got, key)
+	}
+	if !reflect.DeepEqual(tree.ToMap(), map[string]int{"": 10, "gitee.com": 20}) {
+		t.Errorf("ToMap fail: %v", tree.ToMap())
+	}
+}
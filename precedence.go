@@ -0,0 +1,100 @@
+package logs
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"code.gopub.tech/logs/v2/pkg/kv"
+)
+
+// AttrPrecedence decides which value is output when an attr added to the Logger (by With or the KV methods)
+// and a kv added to the ctx (by kv.Add) have the same key, and the order of the attrs in Record.Attr.
+// the text and json formats output the attrs in the order of Record.Attr.
+//
+// 决定 Logger 上的键值对(通过 With 或 KV 方法添加)与 ctx 上的键值对(通过 kv.Add 添加)的键相同时输出哪个值,
+// 以及键值对在 Record.Attr 中的顺序. 文本及 json 格式按 Record.Attr 的顺序输出.
+type AttrPrecedence int
+
+const (
+	// LoggerFirst the attrs of the Logger win, this is the default.
+	// order: the With attrs in the added order, the attrs of the log call, the ctx kvs from the newest to the oldest.
+	//
+	// Logger 上的键值对优先, 这是默认值.
+	// 顺序: 按添加顺序的 With 键值对, 打印日志时传入的键值对, 从新到旧的 ctx 键值对.
+	LoggerFirst AttrPrecedence = iota
+	// ContextFirst the kvs of the ctx win.
+	// order: the ctx kvs from the newest to the oldest, then the attrs of the Logger as LoggerFirst.
+	//
+	// ctx 上的键值对优先.
+	// 顺序: 从新到旧的 ctx 键值对, 之后是与 LoggerFirst 相同顺序的 Logger 键值对.
+	ContextFirst
+	// NewestWins the most recently added one wins, no matter it is added to the Logger or the ctx.
+	// order: the attrs of the log call, then the others from the newest to the oldest.
+	//
+	// 最近添加的优先, 无论是添加到 Logger 还是 ctx 上.
+	// 顺序: 打印日志时传入的键值对, 之后其他键值对从新到旧排列.
+	NewestWins
+)
+
+// WithAttrPrecedence set the precedence of the Logger attrs and the ctx kvs, default is LoggerFirst.
+// see also the handler option `WithDuplicateAttrs` which outputs all of them.
+//
+// 设置 Logger 键值对与 ctx 键值对的优先级, 默认为 LoggerFirst. 另请参见处理器选项 `WithDuplicateAttrs`, 可输出全部的值.
+func WithAttrPrecedence(p AttrPrecedence) LoggerOption {
+	return func(l *logger) { l.precedence = p }
+}
+
+// mergeAttrs merge the attrs of the Logger, the log call and the ctx by the precedence,
+// the duplicate keys are kept, the first one wins when output.
+//
+// 按优先级合并 Logger, 打印日志时传入的以及 ctx 上的键值对. 重复的键会保留, 输出时第一个优先.
+func (l *logger) mergeAttrs(ctx context.Context, call []any) []any {
+	attrs := l.collectAttrs(call)
+	switch l.precedence {
+	case ContextFirst:
+		return concatAttrs(kv.Get(ctx), attrs)
+	case NewestWins:
+		return l.newestFirst(ctx, attrs, len(call) > 0)
+	default:
+		return concatAttrs(attrs, kv.Get(ctx))
+	}
+}
+
+type seqAttr struct {
+	key, value any
+	seq        uint64
+}
+
+// newestFirst sort the attrs by the sequence number, see `kv.NextSeq`.
+//
+// 按序号从新到旧排列键值对, 参见 `kv.NextSeq`.
+func (l *logger) newestFirst(ctx context.Context, attrs []any, hasCallAttrs bool) []any {
+	ctxKVs, ctxSeqs := kv.GetSeq(ctx)
+	var extraSeq uint64 = math.MaxUint64 // the attrs of the log call 打印日志时传入的
+	if !hasCallAttrs {
+		extraSeq = 0
+		for _, g := range l.groups { // the group which contains With attrs only 仅包含 With 键值对的分组
+			if g.seq > extraSeq {
+				extraSeq = g.seq
+			}
+		}
+	}
+	list := make([]seqAttr, 0, (len(attrs)+len(ctxKVs))/2)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		seq := extraSeq
+		if i/2 < len(l.seqs) {
+			seq = l.seqs[i/2]
+		}
+		list = append(list, seqAttr{attrs[i], attrs[i+1], seq})
+	}
+	for i := 0; i+1 < len(ctxKVs); i += 2 {
+		list = append(list, seqAttr{ctxKVs[i], ctxKVs[i+1], ctxSeqs[i/2]})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].seq > list[j].seq })
+	result := make([]any, 0, len(list)*2)
+	for _, a := range list {
+		result = append(result, a.key, a.value)
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package logs
+
+import (
+	"bytes"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/kv"
+)
+
+func TestWithAttrPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		precedence AttrPrecedence
+		keepDup    bool
+		want       string
+	}{
+		{"logger-first", LoggerFirst, false, "a=logger b=old c=call ctx=new"},
+		{"context-first", ContextFirst, false, "ctx=new a=ctx b=ctx c=call"},
+		{"newest-wins", NewestWins, false, "c=call ctx=new b=new a=ctx"},
+		{"logger-first-dup", LoggerFirst, true, "a=logger b=old b=new c=call ctx=new a=ctx b=ctx"},
+		{"newest-wins-dup", NewestWins, true, "c=call ctx=new b=new a=ctx b=ctx b=old a=logger"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := []Option{WithWriter(&buf), WithFormat("%X")}
+			if tt.keepDup {
+				opts = append(opts, WithDuplicateAttrs())
+			}
+			logger := NewLogger(NewHandler(opts...), WithAttrPrecedence(tt.precedence))
+			logger = logger.With("a", "logger").With("b", "old")
+			ctx := kv.Add(ctx, "a", "ctx", "b", "ctx")
+			logger = logger.With("b", "new")
+			ctx = kv.Add(ctx, "ctx", "new")
+			logger.InfoKV(ctx, "msg", "c", "call")
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithAttrPrecedence_emptyCtx(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l Logger)
+		want string
+	}{
+		{"with", func(l Logger) { l.With("b", "new").Info(ctx, "msg") }, "b=new a=logger"},
+		{"call", func(l Logger) { l.InfoKV(ctx, "msg", "b", "call") }, "b=call a=logger"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewLogger(NewHandler(WithWriter(&buf), WithFormat("%X")), WithAttrPrecedence(NewestWins))
+			tt.log(logger.With("a", "logger").With("b", "old"))
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
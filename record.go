@@ -3,6 +3,8 @@ package logs
 import (
 	"context"
 	"time"
+
+	"code.gopub.tech/logs/v2/pkg/caller"
 )
 
 // Record is log record.
@@ -11,9 +13,22 @@ import (
 type Record struct {
 	Ctx    context.Context
 	Time   time.Time
+	Name   string // logger name, see Logger.Named
 	Level  Level
-	PC     uintptr // see pkg/caller package caller.GetFrame 获取调用栈
-	Format string  // message format
-	Args   []any   // message args
-	Attr   []any   // key-value pair of this log. With+ctx 上的 kv
+	PC     uintptr   // log position, 0 if unknown or not to output, see caller.GetFrame 日志位置, 未知或不输出源码时为 0
+	Format string    // message format
+	Args   []any     // message args
+	Attr   []any     // key-value pair of this log, the keys may be duplicate, see kv.Uniq 可能有重复的 key, 处理器输出时保留第一个
+	Stack  []uintptr // call stack, see WithStacktrace 调用栈
+
+	path PathFunc // set by the handler, see WithPath 由处理器设置
+}
+
+// Frame returns the frame of the log position, the path is rewritten if `WithPath` is set on the handler.
+// custom FormatFun should use it instead of caller.GetFrame(r.PC).
+//
+// 返回打印日志处的调用帧, 如果处理器设置了 `WithPath`, 路径会被改写.
+// 自定义格式化函数应使用该方法而不是 caller.GetFrame(r.PC).
+func (r *Record) Frame() caller.Frame {
+	return frameOf(r.PC, r.path)
 }
@@ -0,0 +1,134 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gopub.tech/logs/v2/pkg/redact"
+)
+
+// RedactOption is the option of `WithRedaction`.
+//
+// `WithRedaction` 的选项.
+type RedactOption func(*redactConfig)
+
+type redactConfig struct {
+	keys      []string          // lower case key patterns 小写的键模式
+	scrubbers []redact.Scrubber // scrub the message     对消息脱敏
+	mask      string            // replace the values    替换值的掩码
+}
+
+// RedactKeys mask the values of the attrs whose key matches any of the patterns case-insensitively,
+// `*` matches any characters, such as `password`, `*token*`. the keys in groups are matched too.
+// see redact.DefaultKeys for the common patterns.
+//
+// 对键匹配任一模式(不区分大小写)的键值对的值脱敏, `*` 匹配任意字符, 如 `password`, `*token*`. 分组中的键也会匹配.
+// 常见的模式参见 redact.DefaultKeys.
+func RedactKeys(patterns ...string) RedactOption {
+	return func(c *redactConfig) {
+		for _, p := range patterns {
+			c.keys = append(c.keys, strings.ToLower(p))
+		}
+	}
+}
+
+// RedactMessage scrub the formatted message by the scrubbers, such as redact.CreditCard and redact.Email.
+//
+// 使用 Scrubber 对格式化后的消息脱敏, 如 redact.CreditCard 及 redact.Email.
+func RedactMessage(scrubbers ...redact.Scrubber) RedactOption {
+	return func(c *redactConfig) { c.scrubbers = append(c.scrubbers, scrubbers...) }
+}
+
+// RedactMask set the text which replaces the redacted attr values, default is `******`.
+//
+// 设置替换脱敏值的文本, 默认为 `******`.
+func RedactMask(mask string) RedactOption { return func(c *redactConfig) { c.mask = mask } }
+
+// WithRedaction mask the sensitive data of the Record before output, see `RedactKeys` and `RedactMessage`.
+// the struct fields tagged `log:"redact"` are always masked in json output, and the values wrapped by
+// redact.New are always output as `******`, whether this option is set or not.
+//
+//	logs.NewHandler(logs.WithRedaction(
+//		logs.RedactKeys(redact.DefaultKeys...),
+//		logs.RedactMessage(redact.CreditCard, redact.Email),
+//	))
+//
+// 输出前对日志中的敏感数据脱敏, 参见 `RedactKeys` 及 `RedactMessage`.
+// 无论是否设置该选项, json 输出中带有 `log:"redact"` 标签的结构体字段总会被脱敏, redact.New 包装的值总是输出为 `******`.
+func WithRedaction(opts ...RedactOption) Option {
+	return func(h *handler) {
+		c := &redactConfig{mask: redact.Mask}
+		for _, op := range opts {
+			op(c)
+		}
+		h.redact = c
+	}
+}
+
+// apply redact the attrs and the message of the Record.
+//
+// 对日志的键值对及消息脱敏.
+func (c *redactConfig) apply(r *Record) {
+	if len(c.keys) > 0 {
+		r.Attr, _ = mapAttrs(r.Attr, func(key, value any) (any, bool) {
+			if c.match(key) {
+				return c.mask, true
+			}
+			return value, false
+		})
+	}
+	if len(c.scrubbers) > 0 {
+		msg := message(r)
+		for _, scrub := range c.scrubbers {
+			msg = scrub(msg)
+		}
+		r.Format, r.Args = escapeFormat(msg), nil
+	}
+}
+
+func (c *redactConfig) match(key any) bool {
+	s, ok := key.(string)
+	if !ok {
+		s = fmt.Sprintf("%v", key)
+	}
+	for _, pattern := range c.keys {
+		if matchKey(pattern, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKey reports whether the key matches the lower case pattern case-insensitively, `*` matches any characters.
+//
+// 返回键是否匹配小写的模式(不区分大小写), `*` 匹配任意字符.
+func matchKey(pattern, key string) bool {
+	p, k := 0, 0
+	star, next := -1, 0 // 上一个 * 的位置, 及其匹配到的键的位置
+	for k < len(key) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, next = p, k
+			p++
+		case p < len(pattern) && pattern[p] == toLower(key[k]):
+			p++
+			k++
+		case star >= 0: // 回溯: 让上一个 * 多匹配一个字符
+			next++
+			p, k = star+1, next
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+func toLower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
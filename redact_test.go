@@ -0,0 +1,86 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/arg"
+	"code.gopub.tech/logs/v2/pkg/redact"
+)
+
+func Test_matchKey(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"password", "password", true},
+		{"password", "Password", true},
+		{"password", "password2", false},
+		{"*token*", "token", true},
+		{"*token*", "X-Access-Token", true},
+		{"*token*", "tokens", true},
+		{"*token*", "tok", false},
+		{"*_key", "api_key", true},
+		{"*_key", "api_keys", false},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b*c", "aXcYb", false},
+		{"*", "", true},
+		{"", "", true},
+		{"", "a", false},
+	}
+	for _, tt := range tests {
+		if got := matchKey(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("matchKey(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+type loginRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password" log:"redact"`
+}
+
+func TestWithRedaction(t *testing.T) {
+	var redacted, plain bytes.Buffer
+	logger := NewLogger(CombineHandlers(
+		NewHandler(WithWriter(&redacted), WithRedaction(
+			RedactKeys(redact.DefaultKeys...),
+			RedactMessage(redact.CreditCard, redact.Email),
+		)),
+		NewHandler(WithWriter(&plain)),
+	)).With("Authorization", "Bearer abc")
+	logger.WithGroup("req").InfoKV(context.Background(), "login alice@example.com 100%",
+		"user", "alice", F.String("access_token", "t1"), "n", 1)
+	logger.Info(context.Background(), "card %s", "4111 1111 1111 1111")
+
+	got := redacted.String()
+	for _, want := range []string{
+		"Authorization=****** req.user=alice req.access_token=****** req.n=1 login ****** 100%\n",
+		"Authorization=****** card ************1111\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in %s", want, got)
+		}
+	}
+	if got := plain.String(); !strings.Contains(got, "Authorization=Bearer abc req.user=alice req.access_token=t1 req.n=1 login alice@example.com 100%\n") {
+		t.Errorf("the attrs of the other handler should not be redacted: %s", got)
+	}
+
+	redacted.Reset()
+	logger = NewLogger(NewHandler(WithWriter(&redacted), WithJSON(), WithRedaction(RedactKeys("secret"), RedactMask("[hidden]"))))
+	req := loginRequest{User: "alice", Password: "p"}
+	logger.InfoKV(context.Background(), "login", "req", req, F.Any("any", req), "secret", redact.New("s"), "SECRET", 1)
+	logger.Info(context.Background(), "req=%v", arg.JSON(req))
+	got = redacted.String()
+	for _, want := range []string{
+		`"req":{"user":"alice","password":"******"},"any":{"user":"alice","password":"******"},"secret":"[hidden]","SECRET":"[hidden]","msg":"login"}`,
+		`"msg":"req={\"user\":\"alice\",\"password\":\"******\"}"}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %s in %s", want, got)
+		}
+	}
+}
@@ -1,20 +1,22 @@
 //go:build go1.21
 
-package logs // import "code.gopub.tech/logs"
+package logs // import "code.gopub.tech/logs/v2"
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"log/slog"
+	"math"
+	"runtime"
+
+	"code.gopub.tech/logs/v2/pkg/redact"
 )
 
 var _ slog.Handler = (*SlogHandler)(nil)
 
 // SlogHandler 实现 slog.Handler 适配 logs
 //
-//	import "code.gopub.tech/logs"
+//	import "code.gopub.tech/logs/v2"
 //	// use logs.Default()
 //	slog.SetDefault(slog.New(logs.NewSlogHandler()))
 //	slog.Info("Hello, Log", "key", "value")
@@ -27,6 +29,10 @@ type SlogHandler struct {
 	logger Logger      // 关联的 Logger
 	attrs  []slog.Attr // 所有的属性
 	index  []int       // 记录当前 group 在 attrs 中的下标
+
+	level    slog.Leveler                                 // 覆盖 Logger 的级别
+	replace  func(groups []string, a slog.Attr) slog.Attr // 替换属性
+	noSource bool                                         // 不输出源码信息
 }
 
 // NewSlogHandler 新建一个实现了 slog.Handler 的实例
@@ -34,6 +40,33 @@ func NewSlogHandler() *SlogHandler {
 	return new(SlogHandler)
 }
 
+// NewSlogHandlerWithOptions create a SlogHandler with the slog.HandlerOptions, nil opts is the same as zero options.
+//   - Level: if set, it overrides the level of the Logger and its Handler, the Handler does not check the level again.
+//   - ReplaceAttr: called for every non-group attr with the names of the groups it is in, the attr is removed
+//     if the returned attr is zero. like slog, it is called for the built-in time, level, source (with AddSource)
+//     and message attrs with nil groups too: the returned time, slog.Level and message are used, the source is
+//     removed if the returned attr is zero, and the message is cleared. since they are output by the format of the
+//     Handler, their keys can not be renamed, and the time and level can not be removed.
+//   - AddSource: output the source info (pkg, fun, path, file and line) or not, like slog, Record.PC is 0 if not.
+//
+// 使用 slog.HandlerOptions 创建 SlogHandler, opts 为 nil 时与零值选项相同.
+//   - Level: 如果设置, 将覆盖 Logger 及其 Handler 的级别, Handler 不再判断级别.
+//   - ReplaceAttr: 对每个非分组属性调用, 参数为属性所在分组的名称, 返回零值属性时移除该属性.
+//     与 slog 一致, 也会以 nil 分组对内置的时间, 级别, 源码位置(设置了 AddSource 时)及消息属性调用: 使用返回的时间,
+//     slog.Level 及消息, 返回零值属性时移除源码位置, 清空消息. 由于它们由 Handler 的格式输出,
+//     因此不能重命名这些属性, 也不能移除时间及级别.
+//   - AddSource: 是否输出源码信息(包名, 函数, 路径, 文件及行号), 与 slog 一致, 不输出时 Record.PC 为 0.
+func NewSlogHandlerWithOptions(opts *slog.HandlerOptions) *SlogHandler {
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+	return &SlogHandler{
+		level:    opts.Level,
+		replace:  opts.ReplaceAttr,
+		noSource: !opts.AddSource,
+	}
+}
+
 // SetLogger 设置关联的 Logger
 func (s *SlogHandler) SetLogger(logger Logger) *SlogHandler {
 	s.logger = logger
@@ -46,11 +79,25 @@ func (s *SlogHandler) GetLogger() Logger {
 	var l = s.getLogger()
 	// 将 Attrs 添加到 Logger 上
 	for _, attr := range removeEmptyGroup(s.attrs) {
-		l = l.With(attr.Key, value(attr.Value))
+		l = l.With(attr.Key, fromSlogValue(attr.Value))
 	}
 	return l
 }
 
+// fromSlogValue 将 slog.Value 转为 Record.Attr 中的值, slog 的 Group 转为本库的 Group
+// 这样与 Logger.WithGroup 的输出一致
+func fromSlogValue(v slog.Value) any {
+	v = v.Resolve() // resolve KindLogValuer
+	if v.Kind() != slog.KindGroup {
+		return value(v)
+	}
+	g := make(Group, 0, 2*len(v.Group()))
+	for _, attr := range v.Group() {
+		g = append(g, attr.Key, fromSlogValue(attr.Value))
+	}
+	return g
+}
+
 func (s *SlogHandler) getLogger() Logger {
 	var l = s.logger
 	if l == nil {
@@ -84,24 +131,141 @@ func removeEmptyGroup(attrs []slog.Attr) []slog.Attr {
 }
 
 // Enabled implements slog.Handler.
-func (s *SlogHandler) Enabled(_ context.Context, l slog.Level) bool {
+func (s *SlogHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	if s.level != nil {
+		return l >= s.level.Level()
+	}
 	logger := s.getLogger()
-	return logger.EnableDepth(fromSlogLevel(l), 1)
+	return logger.EnableContextDepth(ctx, FromSlogLevel(l), 1)
+}
+
+// LevelSlog set the slog.Level of the level, see `RegisterLevel`.
+//
+// 设置级别对应的 slog.Level, 参见 `RegisterLevel`.
+func LevelSlog(l slog.Level) LevelOption {
+	return func(info *levelInfo) {
+		info.slogLevel = int(l)
+		info.hasSlog = true
+	}
+}
+
+// defaultSlogLevels is the slog.Level of the built-in levels, the levels registered with `LevelSlog` take precedence.
+//
+// 内置级别对应的 slog.Level, 通过 `LevelSlog` 注册的级别优先.
+var defaultSlogLevels = map[Level]slog.Level{
+	LevelTrace:  slog.LevelDebug - 4, // -8
+	LevelDebug:  slog.LevelDebug,     // -4
+	LevelInfo:   slog.LevelInfo,      // 0
+	LevelNotice: slog.LevelInfo + 2,  // 2
+	LevelWarn:   slog.LevelWarn,      // 4
+	LevelError:  slog.LevelError,     // 8
+	LevelPanic:  slog.LevelError + 4, // 12
+	LevelFatal:  slog.LevelError + 8, // 16
+}
+
+// slogAnchor returns the slog.Level of the registered level, explicit is true if it is set by `LevelSlog`.
+//
+// 返回已注册级别对应的 slog.Level, 通过 `LevelSlog` 设置时 explicit 为 true.
+func slogAnchor(info *levelInfo) (l slog.Level, explicit, ok bool) {
+	if info.hasSlog {
+		return slog.Level(info.slogLevel), true, true
+	}
+	l, ok = defaultSlogLevels[info.level]
+	return l, false, ok
+}
+
+// ToSlogLevel convert the level to slog.Level. the registered levels which have a slog.Level
+// (the built-in levels and the levels registered with `LevelSlog`) are the anchors,
+// a level between them keeps its offset to the nearest lower anchor, such as NOTICE+1 -> INFO+3.
+// the built-in anchors: TRACE=DEBUG-4, DEBUG, INFO, NOTICE=INFO+2, WARN, ERROR, PANIC=ERROR+4, FATAL=ERROR+8.
+// FromSlogLevel(ToSlogLevel(l)) == l if the offset is less than the distance to the next slog anchor.
+//
+// 将级别转为 slog.Level. 有对应 slog.Level 的已注册级别(内置级别及通过 `LevelSlog` 注册的级别)作为锚点,
+// 锚点之间的级别保留与最近的较低锚点的偏移, 如 NOTICE+1 -> INFO+3.
+// 内置锚点: TRACE=DEBUG-4, DEBUG, INFO, NOTICE=INFO+2, WARN, ERROR, PANIC=ERROR+4, FATAL=ERROR+8.
+// 偏移小于到下一个 slog 锚点的距离时, FromSlogLevel(ToSlogLevel(l)) == l.
+func ToSlogLevel(l Level) slog.Level {
+	var anchor *levelInfo
+	var anchorSlog slog.Level
+	for _, info := range registeredLevels() { // 按级别排序
+		s, _, ok := slogAnchor(info)
+		if !ok {
+			continue
+		}
+		if anchor == nil || info.level <= l {
+			anchor, anchorSlog = info, s
+		}
+		if info.level >= l {
+			break
+		}
+	}
+	if anchor == nil {
+		return slog.Level(l)
+	}
+	return slog.Level(addClamp(int(anchorSlog), int(l)-int(anchor.level)))
+}
+
+// FromSlogLevel convert the slog.Level to level, the reverse of `ToSlogLevel`,
+// such as INFO+2 -> NOTICE, INFO+3 -> NOTICE+1, ERROR+8 -> FATAL.
+// ToSlogLevel(FromSlogLevel(l)) == l with the built-in anchors, so the original slog.Level is not lost.
+// if multiple levels have the same slog.Level, the one registered with `LevelSlog` wins.
+//
+// 将 slog.Level 转为级别, 是 `ToSlogLevel` 的逆操作, 如 INFO+2 -> NOTICE, INFO+3 -> NOTICE+1, ERROR+8 -> FATAL.
+// 使用内置锚点时 ToSlogLevel(FromSlogLevel(l)) == l, 不会丢失原始的 slog.Level. 如果多个级别对应同一个 slog.Level, 通过 `LevelSlog` 注册的级别优先.
+func FromSlogLevel(l slog.Level) Level {
+	var anchor, lowest *levelInfo
+	var anchorSlog, lowestSlog slog.Level
+	var anchorExplicit bool
+	for _, info := range registeredLevels() {
+		s, explicit, ok := slogAnchor(info)
+		if !ok {
+			continue
+		}
+		if lowest == nil || s < lowestSlog {
+			lowest, lowestSlog = info, s
+		}
+		if s > l {
+			continue
+		}
+		if anchor == nil || s > anchorSlog || (s == anchorSlog && explicit && !anchorExplicit) {
+			anchor, anchorSlog, anchorExplicit = info, s, explicit
+		}
+	}
+	if anchor == nil { // 低于所有锚点
+		anchor, anchorSlog = lowest, lowestSlog
+	}
+	if anchor == nil {
+		return Level(l)
+	}
+	return Level(addClamp(int(anchor.level), int(l)-int(anchorSlog)))
 }
 
-// fromSlogLevel 将 slog.Level 转为本 logs 库中的 Level
-func fromSlogLevel(l slog.Level) Level {
+// addClamp returns a+b, clamped to the range of int instead of overflow.
+//
+// 返回 a+b, 溢出时限制在 int 的范围内.
+func addClamp(a, b int) int {
 	switch {
-	case l < slog.LevelDebug: // -4
-		return LevelTrace
-	case l < slog.LevelInfo: // 0
-		return LevelDebug
-	case l < slog.LevelWarn: // 4
-		return LevelInfo
-	case l < slog.LevelError: // 8
-		return LevelWarn
-	default: // >= slog.LevelError
-		return LevelError
+	case b > 0 && a > math.MaxInt-b:
+		return math.MaxInt
+	case b < 0 && a < math.MinInt-b:
+		return math.MinInt
+	}
+	return a + b
+}
+
+func init() {
+	resolveSlog = func(v any) (any, bool) {
+		switch x := v.(type) {
+		case slog.LogValuer:
+			return fromSlogValue(slog.AnyValue(x)), true
+		case value: // the attrs from slog hold a Valuer in the slog.Value 来自 slog 的属性的 slog.Value 中持有 Valuer
+			if sv := slog.Value(x); sv.Kind() == slog.KindAny {
+				if lv, ok := sv.Any().(Valuer); ok {
+					return lv, true
+				}
+			}
+		}
+		return v, false
 	}
 }
 
@@ -110,8 +274,15 @@ type ctxKeyRecord struct{}
 var CtxKeyRecord ctxKeyRecord
 
 // Handle implements slog.Handler.
+// the Panic and Fatal levels (ERROR+4 and above) are output only, a slog.Handler must not panic or exit.
 func (s *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if s.level != nil && r.Level < s.level.Level() {
+		return nil
+	}
 	h := s.clone()
+	// 与 slog 一致, 先对内置属性调用 ReplaceAttr
+	builtins := r
+	noSource := s.replaceBuiltins(&builtins)
 	var attrs = make([]slog.Attr, 0, r.NumAttrs())
 	// 收集日志条目上的 Attrs
 	r.Attrs(func(attr slog.Attr) bool {
@@ -123,23 +294,55 @@ func (s *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
 
 	// 将 slog.Record 带上，以备有的 Handler 需要获取
 	ctx = context.WithValue(ctx, CtxKeyRecord, r)
+	// 使用 slog 获取的调用位置; slog.Handler 不能 panic 或退出程序, Panic 及 Fatal 级别(如 slog.LevelError+4)只输出
+	// 设置了 HandlerOptions.Level 时已由它决定输出, Handler 不再判断级别
+	ctx = withCallSite(ctx, callSite{pc: r.PC, time: builtins.Time, noSource: noSource, noExit: true, levelChecked: s.level != nil})
 	// 打印日志
-	// slog.Info -> slog.log -> Handle
-	h.GetLogger().Log(ctx, 3, fromSlogLevel(r.Level), r.Message)
+	h.GetLogger().Log(ctx, 0, FromSlogLevel(builtins.Level), escapeFormat(builtins.Message))
 	return nil
 }
 
+// replaceBuiltins 以 nil 分组对内置的时间, 级别, 源码位置及消息属性调用 HandlerOptions.ReplaceAttr,
+// 返回是否不输出源码信息
+func (s *SlogHandler) replaceBuiltins(r *slog.Record) (noSource bool) {
+	noSource = s.noSource
+	if s.replace == nil {
+		return noSource
+	}
+	if !r.Time.IsZero() {
+		if a := s.replace(nil, slog.Time(slog.TimeKey, r.Time)); a.Value.Kind() == slog.KindTime {
+			r.Time = a.Value.Time()
+		}
+	}
+	if a := s.replace(nil, slog.Any(slog.LevelKey, r.Level)); a.Value.Kind() == slog.KindAny {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			r.Level = level
+		}
+	}
+	if !noSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		source := &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}
+		if a := s.replace(nil, slog.Any(slog.SourceKey, source)); a.Equal(slog.Attr{}) {
+			noSource = true
+		}
+	}
+	if a := s.replace(nil, slog.String(slog.MessageKey, r.Message)); a.Equal(slog.Attr{}) {
+		r.Message = ""
+	} else {
+		r.Message = a.Value.String()
+	}
+	return noSource
+}
+
 // clone 复制一个 Handler 实例
 func (s *SlogHandler) clone() *SlogHandler {
-	return &SlogHandler{
-		logger: s.logger,
-		attrs:  s.attrs,
-		index:  s.index,
-	}
+	c := *s
+	return &c
 }
 
 // addAttrs 添加 Attrs
 func (h *SlogHandler) addAttrs(attrs []slog.Attr) {
+	attrs = h.replaceAttrs(h.groupNames(), attrs)
 	var flat = flatten(make([]slog.Attr, 0, len(attrs)), attrs)
 	var group = h.currentGroup()
 	if group == nil {
@@ -166,6 +369,42 @@ func flatten(flat, attrs []slog.Attr) []slog.Attr {
 	return flat
 }
 
+// replaceAttrs 对每个非分组属性调用 HandlerOptions.ReplaceAttr, groups 为属性所在分组的名称
+func (h *SlogHandler) replaceAttrs(groups []string, attrs []slog.Attr) []slog.Attr {
+	if h.replace == nil {
+		return attrs
+	}
+	result := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		if attr.Value.Kind() == slog.KindGroup {
+			names := groups
+			if attr.Key != "" { // 空名称的 Group 会被打平, 不算一层分组
+				names = append(groups[:len(groups):len(groups)], attr.Key)
+			}
+			attr.Value = slog.GroupValue(h.replaceAttrs(names, attr.Value.Group())...)
+		} else if attr = h.replace(groups, attr); attr.Equal(slog.Attr{}) {
+			continue // 返回零值时移除该属性
+		}
+		result = append(result, attr)
+	}
+	return result
+}
+
+// groupNames 返回当前各层 Group 的名称
+func (h *SlogHandler) groupNames() (names []string) {
+	var group *slog.Attr
+	for _, i := range h.index {
+		if group == nil {
+			group = &h.attrs[i]
+		} else {
+			group = &(group.Value.Group()[i])
+		}
+		names = append(names, group.Key)
+	}
+	return
+}
+
 // currentGroup 返回当前的 Group 如果还没有 Group 返回 nil
 // attrs: [kv] index=[] -> nil
 // attrs: [kv, group1] index=[1] -> group1
@@ -226,24 +465,5 @@ func (v value) String() string {
 func (v value) MarshalJSON() ([]byte, error) {
 	sv := slog.Value(v)
 	sv = sv.Resolve() // resolve KindLogValuer
-	switch sv.Kind() {
-	case slog.KindGroup:
-		var buf bytes.Buffer
-		buf.WriteRune('{')
-		for i, attr := range sv.Group() {
-			if i > 0 {
-				buf.WriteRune(',')
-			}
-			buf.WriteString(fmt.Sprintf("%q:", attr.Key))
-			b, err := json.Marshal(value(attr.Value)) // 继续转为 value 类型，递归转 json
-			if err != nil {
-				return nil, err
-			}
-			buf.Write(b)
-		}
-		buf.WriteRune('}')
-		return buf.Bytes(), nil
-	default:
-		return json.Marshal(sv.Any())
-	}
+	return json.Marshal(redact.Struct(sv.Any()))
 }
@@ -0,0 +1,107 @@
+//go:build go1.21
+
+package logs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"code.gopub.tech/logs/v2/pkg/kv"
+)
+
+// FromSlogHandler returns a Handler which outputs the logs by the slog.Handler, such as slog.NewJSONHandler,
+// it is the reverse of `SlogHandler`.
+// the Record is converted to slog.Record: the level is mapped by `ToSlogLevel`, the message is formatted,
+// the PC is kept for the source, the logger name is added as the `logger` attr, and the `Group` values
+// are converted to slog groups. Enable and EnableContext are mapped to slog.Handler.Enabled.
+//
+//	logger := logs.NewLogger(logs.FromSlogHandler(slog.NewJSONHandler(os.Stderr, nil)))
+//
+// 返回使用 slog.Handler (如 slog.NewJSONHandler) 输出日志的 Handler, 是 `SlogHandler` 的反向适配.
+// Record 会转为 slog.Record: 级别通过 `ToSlogLevel` 转换, 消息是格式化后的, 保留 PC 用于源码位置,
+// logger 名称作为 `logger` 属性添加, `Group` 值转为 slog 的分组. Enable 及 EnableContext 对应 slog.Handler.Enabled.
+func FromSlogHandler(h slog.Handler) Handler {
+	return &slogHandler{h: h}
+}
+
+// slogHandler adapts a slog.Handler to Handler.
+//
+// 将 slog.Handler 适配为 Handler.
+type slogHandler struct {
+	h slog.Handler
+}
+
+func (s *slogHandler) Output(r Record) {
+	if !s.h.Enabled(contextOf(&r), ToSlogLevel(r.Level)) {
+		return
+	}
+	s.outputChecked(r)
+}
+
+func (s *slogHandler) outputChecked(r Record) {
+	ctx := contextOf(&r)
+	sr := slog.NewRecord(r.Time, ToSlogLevel(r.Level), message(&r), r.PC)
+	if r.Name != "" {
+		sr.AddAttrs(slog.String("logger", r.Name))
+	}
+	sr.AddAttrs(toSlogAttrs(resolveAttrs(kv.Uniq(r.Attr)))...)
+	if len(r.Stack) > 0 {
+		sr.AddAttrs(slog.Any("stack", stackFrames(r.Stack, r.path)))
+	}
+	_ = s.h.Handle(ctx, sr)
+}
+
+func (s *slogHandler) levelNeedCaller(string) bool { return false }
+
+func contextOf(r *Record) context.Context {
+	if r.Ctx == nil {
+		return context.Background()
+	}
+	return r.Ctx
+}
+
+func (s *slogHandler) Enable(level Level, pc uintptr) bool {
+	return s.h.Enabled(context.Background(), ToSlogLevel(level))
+}
+
+func (s *slogHandler) EnableContext(ctx context.Context, name string, level Level, pc uintptr) bool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return s.h.Enabled(ctx, ToSlogLevel(level))
+}
+
+// toSlogAttrs convert the attrs to slog.Attr, the Group is converted to slog group.
+//
+// 将键值对转为 slog.Attr, Group 转为 slog 的分组.
+func toSlogAttrs(attrs []any) []slog.Attr {
+	result := make([]slog.Attr, 0, len(attrs)/2)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key, ok := attrs[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", attrs[i])
+		}
+		result = append(result, slog.Attr{Key: key, Value: toSlogValue(attrs[i+1])})
+	}
+	return result
+}
+
+// toSlogValue convert the attr value to slog.Value, the reverse of fromSlogValue.
+//
+// 将键值对的值转为 slog.Value, 是 fromSlogValue 的逆操作.
+func toSlogValue(v any) slog.Value {
+	switch x := v.(type) {
+	case Group:
+		return slog.GroupValue(toSlogAttrs(x)...)
+	case value:
+		return slog.Value(x)
+	case Field:
+		switch x.Kind {
+		case KindError, KindAny:
+			return slog.AnyValue(x.any)
+		}
+		return slog.AnyValue(x.Value())
+	}
+	return slog.AnyValue(v)
+}
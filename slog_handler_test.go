@@ -0,0 +1,76 @@
+//go:build go1.21
+
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := FromSlogHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug}))
+	logger := NewLogger(h).Named("db").With("a", 1).WithGroup("G")
+	if logger.Enable(LevelTrace) || !logger.Enable(LevelDebug) || !logger.EnableContext(nil, LevelDebug) {
+		t.Errorf("Enable should be mapped to slog.Handler.Enabled")
+	}
+	logger.Trace(context.Background(), "disabled")
+	logger.DebugKV(context.Background(), "100% done", "k", "v", F.Int("n", 2), "u", user{id: 1, name: "alice"},
+		"err", errors.New("boom"), F.Duration("d", time.Second))
+	logger.Notice(context.Background(), "hello %s", "world")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+	var m struct {
+		Level  string
+		Msg    string
+		Logger string
+		A      int
+		G      map[string]any
+		Source struct {
+			Function string
+			File     string
+		}
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Level != "DEBUG" || m.Msg != "100% done" || m.Logger != "db" || m.A != 1 {
+		t.Errorf("unexpected record: %s", lines[0])
+	}
+	if !strings.HasSuffix(m.Source.Function, "TestFromSlogHandler") || !strings.HasSuffix(m.Source.File, "slog_handler_test.go") {
+		t.Errorf("the PC should be kept: %s", lines[0])
+	}
+	want := map[string]any{"k": "v", "n": float64(2), "u": map[string]any{"id": float64(1), "name": "alice"}, "err": "boom", "d": float64(time.Second)}
+	for k, v := range want {
+		if got, _ := json.Marshal(m.G[k]); string(got) != mustJSON(v) {
+			t.Errorf("G.%s = %s, want %s", k, got, mustJSON(v))
+		}
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &m); err != nil || m.Level != "INFO+2" || m.Msg != "hello world" {
+		t.Errorf("unexpected record: %s", lines[1])
+	}
+}
+
+func TestFromSlogHandler_roundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	// slog -> logs -> slog
+	inner := NewLogger(FromSlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	slog.New(NewSlogHandler().SetLogger(inner)).WithGroup("G").Debug("msg", "k", "v", slog.Group("H", "x", 1))
+	if got, want := buf.String(), `level=DEBUG msg=msg G.k=v G.H.x=1`; !strings.Contains(got, want) {
+		t.Errorf("got= %s, want %s", got, want)
+	}
+}
+
+func mustJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
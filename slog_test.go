@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -16,8 +17,8 @@ import (
 	"testing/slogtest"
 	"time"
 
-	"code.gopub.tech/logs/pkg/caller"
-	"code.gopub.tech/logs/pkg/trie"
+	"code.gopub.tech/logs/v2/pkg/caller"
+	"code.gopub.tech/logs/v2/pkg/trie"
 )
 
 func ExampleSlogHandler() {
@@ -46,11 +47,11 @@ func ExampleSlogHandler() {
 func TestSlogHandler(t *testing.T) {
 	for _, test := range []struct {
 		name  string
-		new   func(io.Writer) slog.Handler
+		new   func(io.Writer) Logger
 		parse func([]byte) (map[string]any, error)
 	}{
-		{"JSON", func(w io.Writer) slog.Handler {
-			return NewSlogHandler().SetLogger(NewLogger(NewHandler(WithWriter(w), WithFormatFun(func(r *Record) string {
+		{"JSON", func(w io.Writer) Logger {
+			return NewLogger(NewHandler(WithWriter(w), WithFormatFun(func(r *Record) string {
 				var sb strings.Builder
 				// {"time":"","level":"","pkg":"","fun":"","path":"","file":"","line":0,"msg":"","key":"value"}
 				sb.WriteString(`{"ts":`)
@@ -87,13 +88,13 @@ func TestSlogHandler(t *testing.T) {
 				sb.WriteString(strconv.Quote(msg))
 				sb.WriteString("}\n")
 				return sb.String()
-			}))))
+			})))
 		}, parseJSON},
-		{"Text", func(w io.Writer) slog.Handler {
-			return NewSlogHandler().SetLogger(NewLogger(NewHandler(WithWriter(w), WithFormatFun(func(r *Record) string {
+		{"Text", func(w io.Writer) Logger {
+			return NewLogger(NewHandler(WithWriter(w), WithFormatFun(func(r *Record) string {
 				ts := r.Time.Format(timeFormatOnText)
 				frame := caller.GetFrame(r.PC)
-				var sb strings.Builder
+				var sb bytes.Buffer
 				sr := r.Ctx.Value(CtxKeyRecord).(slog.Record)
 				if sr.Time == (time.Time{}) {
 					sb.WriteString(fmt.Sprintf("level=%s pkg=%s fun=%s path=%s file=%s line=%d ",
@@ -106,37 +107,41 @@ func TestSlogHandler(t *testing.T) {
 						ifEmpty(frame.Path, "?"), ifEmpty(frame.File, "???"), frame.Line))
 				}
 
-				attrs := r.Attr
-				for len(attrs) > 1 {
-					// slogtest 要求嵌套 group 按 group.inner.key=value 形式展开打印
-					// [todo]
-					sb.WriteString(fmt.Sprintf("%v=%v ", attrs[0], attrs[1]))
-					attrs = attrs[2:]
-				}
+				// slogtest 要求嵌套 group 按 group.inner.key=value 形式展开打印
+				writeTextAttrs(&sb, "", r.Attr)
 				sb.WriteString(fmt.Sprintf("msg="+r.Format, r.Args...))
 				sb.WriteRune('\n')
 				t.Log(sb.String())
 				return sb.String()
-			}))))
+			})))
 		}, parseText},
 	} {
-		if test.name == "Text" {
-			t.Skip("Skip Text formtat test")
-		}
-		t.Run(test.name, func(t *testing.T) {
-			var buf bytes.Buffer
-			h := test.new(&buf)
-			results := func() []map[string]any {
-				ms, err := parseLines(buf.Bytes(), test.parse)
-				if err != nil {
+		for _, handler := range []struct {
+			name string
+			sh   *SlogHandler
+		}{
+			{"", NewSlogHandler()},
+			{"/WithOptions", NewSlogHandlerWithOptions(&slog.HandlerOptions{
+				AddSource:   true,
+				Level:       slog.LevelDebug,
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr { return a },
+			})},
+		} {
+			t.Run(test.name+handler.name, func(t *testing.T) {
+				var buf bytes.Buffer
+				h := handler.sh.SetLogger(test.new(&buf))
+				results := func() []map[string]any {
+					ms, err := parseLines(buf.Bytes(), test.parse)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return ms
+				}
+				if err := slogtest.TestHandler(h, results); err != nil {
 					t.Fatal(err)
 				}
-				return ms
-			}
-			if err := slogtest.TestHandler(h, results); err != nil {
-				t.Fatal(err)
-			}
-		})
+			})
+		}
 	}
 }
 
@@ -196,34 +201,66 @@ func parseText(bs []byte) (map[string]any, error) {
 	return top, nil
 }
 
-func Test_fromSlogLevel(t *testing.T) {
-	type args struct {
-		l slog.Level
-	}
+func TestFromSlogLevel(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
+		l    slog.Level
 		want Level
 	}{
-		{args: args{slog.LevelDebug - 5}, want: LevelTrace},
-		{args: args{slog.LevelDebug - 4}, want: LevelTrace},
-		{args: args{slog.LevelDebug - 1}, want: LevelTrace},
-		{args: args{slog.LevelDebug}, want: LevelDebug},
-		{args: args{slog.LevelInfo - 1}, want: LevelDebug},
-		{args: args{slog.LevelInfo}, want: LevelInfo},
-		{args: args{slog.LevelWarn - 1}, want: LevelInfo},
-		{args: args{slog.LevelWarn}, want: LevelWarn},
-		{args: args{slog.LevelError - 1}, want: LevelWarn},
-		{args: args{slog.LevelError}, want: LevelError},
-		{args: args{slog.LevelError + 1}, want: LevelError},
+		{slog.LevelDebug - 5, LevelTrace - 1},
+		{slog.LevelDebug - 4, LevelTrace},
+		{slog.LevelDebug - 1, LevelTrace + 3},
+		{slog.LevelDebug, LevelDebug},
+		{slog.LevelInfo - 1, LevelDebug + 3},
+		{slog.LevelInfo, LevelInfo},
+		{slog.LevelInfo + 1, LevelInfo + 1},
+		{slog.LevelInfo + 2, LevelNotice},
+		{slog.LevelWarn - 1, LevelNotice + 1},
+		{slog.LevelWarn, LevelWarn},
+		{slog.LevelError - 1, LevelWarn + 3},
+		{slog.LevelError, LevelError},
+		{slog.LevelError + 1, LevelError + 1},
+		{slog.LevelError + 4, LevelPanic},
+		{slog.LevelError + 8, LevelFatal},
+		{slog.LevelError + 9, LevelFatal + 1},
+		{math.MaxInt, LevelOFF},
+		{math.MinInt, LevelALL},
 	}
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := fromSlogLevel(tt.args.l); got != tt.want {
-				t.Errorf("fromSlogLevel() = %v, want %v", got, tt.want)
+		t.Run(tt.l.String(), func(t *testing.T) {
+			if got := FromSlogLevel(tt.l); got != tt.want {
+				t.Errorf("FromSlogLevel() = %v, want %v", got, tt.want)
+			}
+			if tt.want == LevelOFF || tt.want == LevelALL {
+				return
+			}
+			if got := ToSlogLevel(tt.want); got != tt.l {
+				t.Errorf("ToSlogLevel() = %v, want %v", got, tt.l)
 			}
 		})
 	}
+	for l := slog.Level(-20); l <= 30; l++ {
+		if got := ToSlogLevel(FromSlogLevel(l)); got != l {
+			t.Errorf("ToSlogLevel(FromSlogLevel(%v)) = %v", l, got)
+		}
+	}
+}
+
+func TestToSlogLevel(t *testing.T) {
+	tests := []struct {
+		l    Level
+		want slog.Level
+	}{
+		{LevelTrace - 1, slog.LevelDebug - 5},
+		{LevelDebug + 5, slog.LevelDebug + 5}, // 超出锚点间距, 无法还原
+		{LevelNotice + 1, slog.LevelInfo + 3},
+		{LevelFatal, slog.LevelError + 8},
+		{LevelOFF, math.MaxInt - 50 + 16},
+	}
+	for _, tt := range tests {
+		if got := ToSlogLevel(tt.l); got != tt.want {
+			t.Errorf("ToSlogLevel(%v) = %v, want %v", tt.l, got, tt.want)
+		}
+	}
 }
 
 func TestSlogHandlerGetLogger(t *testing.T) {
@@ -250,3 +287,288 @@ func TestEnable(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestLevelSlog(t *testing.T) {
+	const levelAudit = LevelNotice + 5
+	RegisterLevel(levelAudit, "AUDIT", LevelSlog(slog.LevelInfo+2))
+	defer unregisterLevel(levelAudit)
+	if got := FromSlogLevel(slog.LevelInfo + 2); got != levelAudit {
+		t.Errorf("FromSlogLevel() = %v, want %v", got, levelAudit)
+	}
+}
+
+func TestSlogHandlerNoExit(t *testing.T) {
+	var buf bytes.Buffer
+	var exited bool
+	l := NewLogger(NewHandler(WithWriter(&buf)), WithExitFunc(func(int) { exited = true }))
+	sl := slog.New(NewSlogHandler().SetLogger(l))
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("slog.Handler should not panic: %v", r)
+			}
+		}()
+		sl.Log(context.Background(), slog.LevelError+4, "critical")
+	}()
+	sl.Log(context.Background(), slog.LevelError+8, "fatal")
+	if exited {
+		t.Errorf("slog.Handler should not exit")
+	}
+	got := buf.String()
+	if !strings.Contains(got, "PANIC ") || !strings.Contains(got, "critical") || !strings.Contains(got, "FATAL ") {
+		t.Errorf("the level should be kept for display: %s", got)
+	}
+	defer func() { // 直接使用 Logger 仍会 panic
+		if _, ok := recover().(*PanicError); !ok {
+			t.Errorf("Logger.Panic should panic")
+		}
+	}()
+	l.Panic(context.Background(), "panic")
+}
+
+// wrapLogger is a Logger which is not *logger, such as the Logger of other packages which wraps this one.
+type wrapLogger struct{ Logger }
+
+func TestSlogHandlerWrappedLogger(t *testing.T) {
+	var pcs []uintptr
+	var exited bool
+	h := NewHandler(WithWriter(io.Discard), WithFormatFun(func(r *Record) string {
+		pcs = append(pcs, r.PC)
+		return ""
+	}))
+	l := wrapLogger{NewLogger(h, WithExitFunc(func(int) { exited = true }))}
+	slog.New(NewSlogHandlerWithOptions(nil).SetLogger(l)).Log(context.Background(), slog.LevelError+8, "no source")
+	slog.New(NewSlogHandlerWithOptions(&slog.HandlerOptions{AddSource: true}).SetLogger(l)).Info("source")
+	if len(pcs) != 2 || pcs[0] != 0 || caller.GetFrame(pcs[1]).Fun != "TestSlogHandlerWrappedLogger" {
+		t.Errorf("Record.PC should be 0 without AddSource and the slog PC with it: %v", pcs)
+	}
+	if exited {
+		t.Errorf("slog.Handler should not exit")
+	}
+}
+
+func TestSlogHandlerNamed(t *testing.T) {
+	var buf bytes.Buffer
+	var l = NewLogger(NewHandler(WithWriter(&buf))).Named("db")
+	slog.New(NewSlogHandler().SetLogger(l)).WithGroup("G").Info("msg", "k", "v")
+	if msg := buf.String(); !strings.Contains(msg, "INFO  [db] ") {
+		t.Errorf("unexpected output: %s", msg)
+	}
+}
+
+// BenchmarkSlogWorkloads runs the workloads of BenchmarkWorkloads with the standard slog for comparison.
+//
+// 使用标准库 slog 运行 BenchmarkWorkloads 中的场景, 用于对比.
+func BenchmarkSlogWorkloads(b *testing.B) {
+	attrs := []any{
+		slog.Int("int", 1),
+		slog.Int64("int64", 2),
+		slog.Float64("float", 3.0),
+		slog.String("string", "four!"),
+		slog.Bool("bool", true),
+		slog.Time("time", benchTime),
+		slog.Duration("duration", time.Second),
+		slog.Any("error", benchErr),
+		slog.Any("strings", []string{"a", "b", "c"}),
+		slog.Uint64("uint64", 10),
+	}
+	for _, h := range []struct {
+		name    string
+		handler slog.Handler
+	}{
+		{"Text", slog.NewTextHandler(io.Discard, &slog.HandlerOptions{AddSource: true})},
+		{"JSON", slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{AddSource: true})},
+		{"JSON-WithoutSource", slog.NewJSONHandler(io.Discard, nil)},
+	} {
+		logger := slog.New(h.handler)
+		b.Run(h.name+"/Message", func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.InfoContext(ctx, benchMsg)
+				}
+			})
+		})
+		b.Run(h.name+"/Printf", func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.InfoContext(ctx, fmt.Sprintf("%s: %d %v", benchMsg, 42, true))
+				}
+			})
+		})
+		b.Run(h.name+"/Fields", func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.InfoContext(ctx, benchMsg, attrs...)
+				}
+			})
+		})
+		b.Run(h.name+"/AccumulatedContext", func(b *testing.B) {
+			logger := logger.With(attrs...)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.InfoContext(ctx, benchMsg)
+				}
+			})
+		})
+		b.Run(h.name+"/Disabled", func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.DebugContext(ctx, benchMsg)
+				}
+			})
+		})
+	}
+}
+
+type slogUser struct{ id int }
+
+func (u slogUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("id", u.id), slog.String("role", "admin"))
+}
+
+func TestSlogLogValuer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf), WithJSON()))
+	logger.InfoKV(context.Background(), "native", "user", slogUser{id: 1})
+	if got, want := buf.String(), `"user":{"id":1,"role":"admin"},"msg":"native"}`; !strings.Contains(got, want) {
+		t.Errorf("got= %s\nwant= %s", got, want)
+	}
+}
+
+func TestSlogHandler_valuer(t *testing.T) {
+	var buf bytes.Buffer
+	sl := slog.New(NewSlogHandler().SetLogger(NewLogger(NewHandler(WithWriter(&buf))))).With("user", user{id: 1, name: "alice"})
+	sl.Info("text", "uid", userID(2), slog.Group("g", "any", user{id: 3, name: "bob"}))
+	if got, want := buf.String(), " user.id=1 user.name=alice uid.id=2 uid.name=alice g.any.id=3 g.any.name=bob text\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got= %s\nwant suffix= %s", got, want)
+	}
+
+	buf.Reset()
+	sl = slog.New(NewSlogHandler().SetLogger(NewLogger(NewHandler(WithWriter(&buf), WithJSON()))))
+	sl.Info("json", "user", user{id: 1, name: "alice"})
+	if got, want := buf.String(), `"user":{"id":1,"name":"alice"},"msg":"json"}`; !strings.Contains(got, want) {
+		t.Errorf("got= %s\nwant= %s", got, want)
+	}
+}
+
+func TestNewSlogHandlerWithOptions(t *testing.T) {
+	var buf bytes.Buffer
+	var groups []string
+	l := NewLogger(NewHandler(WithWriter(&buf), WithLevel(LevelWarn)))
+	sh := NewSlogHandlerWithOptions(&slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(gs []string, a slog.Attr) slog.Attr {
+			groups = append(groups, strings.Join(append(gs, a.Key), "."))
+			switch a.Key {
+			case "password":
+				return slog.String(a.Key, "******")
+			case "drop":
+				return slog.Attr{}
+			}
+			return a
+		},
+	}).SetLogger(l)
+	logger := slog.New(sh).With("a", 1).WithGroup("G").With("password", "p").WithGroup("H")
+	if logger.Enabled(context.Background(), slog.LevelDebug-1) || !logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Errorf("HandlerOptions.Level should override the level of the Logger")
+	}
+	logger.Debug("100% done", "drop", true, slog.Group("I", "k", "v", "drop", 1), slog.Group("", "inline", 2))
+	logger.Log(context.Background(), slog.LevelDebug-1, "disabled")
+
+	got := buf.String()
+	if want := "DEBUG a=1 G.password=****** G.H.I.k=v G.H.inline=2 100% done\n"; !strings.HasSuffix(got, want) || strings.Count(got, "\n") != 1 {
+		t.Errorf("got= %q, want suffix %q", got, want)
+	}
+	if strings.Contains(got, "slog_test.go") {
+		t.Errorf("source should not be output without AddSource: %s", got)
+	}
+	if want := "a,G.password,time,level,msg,G.H.drop,G.H.I.k,G.H.I.drop,G.H.inline"; strings.Join(groups, ",") != want {
+		t.Errorf("ReplaceAttr groups = %v, want %v", groups, want)
+	}
+
+	buf.Reset()
+	slog.New(NewSlogHandlerWithOptions(&slog.HandlerOptions{AddSource: true}).SetLogger(l)).Warn("with source")
+	if got := buf.String(); !strings.Contains(got, "slog_test.go:") {
+		t.Errorf("source should be output with AddSource: %s", got)
+	}
+	buf.Reset()
+	at := time.Date(2023, 1, 2, 3, 4, 5, 0, time.Local)
+	slog.New(NewSlogHandlerWithOptions(&slog.HandlerOptions{
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if groups != nil {
+				return a
+			}
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Time(a.Key, at)
+			case slog.LevelKey:
+				return slog.Any(a.Key, slog.LevelError)
+			case slog.SourceKey:
+				return slog.Attr{}
+			case slog.MessageKey:
+				return slog.String(a.Key, "replaced "+a.Value.String())
+			}
+			return a
+		},
+	}).SetLogger(l)).Warn("msg")
+	if got, want := buf.String(), at.Format(timeFormatOnText)+" ERROR replaced msg\n"; got != want {
+		t.Errorf("ReplaceAttr of the built-in attrs: got= %q, want %q", got, want)
+	}
+	buf.Reset()
+	slog.New(NewSlogHandlerWithOptions(nil).SetLogger(l)).Info("info")
+	if got := buf.String(); got != "" {
+		t.Errorf("the level of the Logger should be used without HandlerOptions.Level: %s", got)
+	}
+}
+
+// recordHandler is a Handler of other packages, it does not check the level in Output.
+type recordHandler struct{ records []Record }
+
+func (h *recordHandler) Output(r Record)                     { h.records = append(h.records, r) }
+func (h *recordHandler) Enable(level Level, pc uintptr) bool { return level >= LevelError }
+
+func TestSlogHandler_optionsLevel(t *testing.T) {
+	var a, b, c bytes.Buffer
+	rec := &recordHandler{}
+	handlers := []struct {
+		name string
+		h    Handler
+		out  func() string
+	}{
+		{name: "without-override", h: NewHandler(WithWriter(&a), WithLevel(LevelWarn), WithoutLevelOverride()), out: a.String},
+		{name: "handlers", h: Handlers{NewHandler(WithWriter(&b), WithLevel(LevelWarn)), NewHandler(WithWriter(&c), WithLevel(LevelError))},
+			out: func() string { return b.String() + c.String() }},
+		{name: "other-package", h: rec, out: func() string {
+			if len(rec.records) == 0 {
+				return ""
+			}
+			return rec.records[0].Format
+		}},
+	}
+	for _, tt := range handlers {
+		t.Run(tt.name, func(t *testing.T) {
+			sh := NewSlogHandlerWithOptions(&slog.HandlerOptions{Level: slog.LevelDebug}).SetLogger(NewLogger(tt.h))
+			ctx := WithLevelOverride(context.Background(), LevelError)
+			slog.New(sh).DebugContext(ctx, "debug")
+			slog.New(sh).Log(ctx, slog.LevelDebug-1, "disabled")
+			if got := tt.out(); !strings.Contains(got, "debug") || strings.Contains(got, "disabled") {
+				t.Errorf("HandlerOptions.Level should decide the output: %q", got)
+			}
+		})
+	}
+	if len(rec.records) != 1 {
+		t.Fatalf("records = %d, want 1", len(rec.records))
+	}
+	if level, ok := LevelOverride(rec.records[0].Ctx); !ok || level != LevelError {
+		t.Errorf("the level override of the caller should be kept: %v, %v", level, ok)
+	}
+	if n := strings.Count(b.String()+c.String(), "debug"); n != 2 {
+		t.Errorf("every handler should output the log: %d", n)
+	}
+}
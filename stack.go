@@ -0,0 +1,133 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+
+	"code.gopub.tech/logs/v2/pkg/caller"
+)
+
+// maxStackDepth is the max frames of a captured stack.
+//
+// 捕获调用栈的最大帧数.
+const maxStackDepth = 64
+
+// logsPkg is the package name of this package.
+//
+// 本包的包名.
+var logsPkg = caller.GetFrame(caller.PC(0)).Pkg
+
+// StackOption is the option of `WithStacktrace`.
+//
+// `WithStacktrace` 的选项.
+type StackOption func(*stackConfig)
+
+type stackConfig struct {
+	level       Level // capture stack at or above this level 不低于该级别时捕获调用栈
+	trimRuntime bool  // remove the frames of runtime package 移除 runtime 包的帧
+	trimLogs    bool  // remove the frames of logs package   移除本包的帧
+}
+
+// StackTrimRuntime remove the frames of the runtime package, such as runtime.main and runtime.goexit.
+//
+// 移除 runtime 包的帧, 如 runtime.main 及 runtime.goexit.
+func StackTrimRuntime() StackOption { return func(c *stackConfig) { c.trimRuntime = true } }
+
+// StackTrimLogs remove all frames of the logs package.
+// the frames between the logs call and the capture are always removed.
+//
+// 移除本日志包的所有帧. 从打印日志处到捕获调用栈之间的帧总是会被移除.
+func StackTrimLogs() StackOption { return func(c *stackConfig) { c.trimLogs = true } }
+
+// WithStacktrace capture the call stack for the logs at or above the level,
+// the stack is stored in Record.Stack, and is output as a `stack` field in json
+// and indented frames after the message in text.
+//
+// 为不低于指定级别的日志捕获调用栈, 调用栈存储在 Record.Stack 中,
+// json 格式输出为 `stack` 字段, 文本格式在消息之后缩进输出.
+func WithStacktrace(level Level, opts ...StackOption) Option {
+	return func(h *handler) {
+		h.stack = &stackConfig{level: level}
+		for _, op := range opts {
+			op(h.stack)
+		}
+	}
+}
+
+// capture returns the call stack start from the pc, the frames before it are removed.
+// if the pc is not found, the leading frames of the logs package are removed.
+//
+// 返回从 pc 开始的调用栈, 之前的帧会被移除. 如果找不到 pc, 就移除开头的本包的帧.
+func (c *stackConfig) capture(pc uintptr) []uintptr {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(2, pcs[:])
+	stack := pcs[:n]
+	if i := indexOf(stack, pc); pc != 0 && i >= 0 {
+		stack = stack[i:]
+	} else {
+		for len(stack) > 0 && caller.GetFrame(stack[0]).Pkg == logsPkg {
+			stack = stack[1:]
+		}
+	}
+	result := make([]uintptr, 0, len(stack))
+	for _, pc := range stack {
+		pkg := caller.GetFrame(pc).Pkg
+		if (c.trimRuntime && pkg == "runtime") || (c.trimLogs && pkg == logsPkg) {
+			continue
+		}
+		result = append(result, pc)
+	}
+	return result
+}
+
+func indexOf(pcs []uintptr, pc uintptr) int {
+	for i, p := range pcs {
+		if p == pc {
+			return i
+		}
+	}
+	return -1
+}
+
+// stackFrames returns the frames of the stack as `pkg.fun path/file.go:line`.
+//
+// 以 `pkg.fun path/file.go:line` 形式返回调用栈的每一帧.
+func stackFrames(stack []uintptr, path PathFunc) []string {
+	frames := make([]string, 0, len(stack))
+	for _, pc := range stack {
+		f := frameOf(pc, path)
+		frames = append(frames, fmt.Sprintf("%s.%s %s/%s:%d", f.Pkg, f.Fun, f.Path, f.File, f.Line))
+	}
+	return frames
+}
+
+// writeStackJSON write the stack as a json array of frames.
+//
+// 以 json 数组形式输出调用栈.
+func writeStackJSON(sb *bytes.Buffer, stack []uintptr, path PathFunc) {
+	sb.WriteRune('[')
+	for i, frame := range stackFrames(stack, path) {
+		if i > 0 {
+			sb.WriteRune(',')
+		}
+		sb.WriteString(strconv.Quote(frame))
+	}
+	sb.WriteRune(']')
+}
+
+// writeStackText write the stack as indented frames.
+//
+// 以缩进形式输出调用栈.
+//
+//	stack:
+//	    pkg.fun
+//	        path/file.go:line
+func writeStackText(sb *bytes.Buffer, stack []uintptr, path PathFunc) {
+	sb.WriteString("    stack:\n")
+	for _, pc := range stack {
+		f := frameOf(pc, path)
+		sb.WriteString(fmt.Sprintf("        %s.%s\n            %s/%s:%d\n", f.Pkg, f.Fun, f.Path, f.File, f.Line))
+	}
+}
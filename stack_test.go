@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"code.gopub.tech/logs/v2/pkg/caller"
+)
+
+func TestWithStacktrace(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(NewHandler(WithWriter(&buf), WithJSON(), WithStacktrace(LevelError, StackTrimRuntime())))
+	logger.Warn(ctx, "no stack")
+	logger.Error(ctx, "with stack")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+	var m struct {
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil || m.Stack != nil {
+		t.Errorf("warn log should not have stack: %s", lines[0])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &m); err != nil || len(m.Stack) == 0 {
+		t.Fatalf("error log should have stack: %s", lines[1])
+	}
+	if !strings.HasPrefix(m.Stack[0], "code.gopub.tech/logs/v2.TestWithStacktrace ") {
+		t.Errorf("stack should start from the caller: %v", m.Stack)
+	}
+	for _, frame := range m.Stack {
+		if strings.HasPrefix(frame, "runtime.") {
+			t.Errorf("runtime frames should be removed: %v", m.Stack)
+		}
+	}
+
+	buf.Reset()
+	NewLogger(NewHandler(WithWriter(&buf), WithStacktrace(LevelInfo))).Info(ctx, "msg")
+	if got := buf.String(); !strings.Contains(got, " msg\n    stack:\n        code.gopub.tech/logs/v2.TestWithStacktrace\n            ") {
+		t.Errorf("unexpected text output: %s", got)
+	}
+}
+
+func Test_stackConfig_capture(t *testing.T) {
+	c := &stackConfig{trimLogs: true}
+	stack := c.capture(0) // pc not found
+	if len(stack) == 0 {
+		t.Fatalf("stack should not be empty")
+	}
+	for _, pc := range stack {
+		if caller.GetFrame(pc).Pkg == logsPkg {
+			t.Errorf("logs frames should be removed: %v", stackFrames(stack, nil))
+		}
+	}
+}
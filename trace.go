@@ -0,0 +1,146 @@
+package logs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ContextExtractor returns the attrs extracted from the ctx, such as the trace id, see `WithContextExtractor`.
+//
+// 从 ctx 中提取键值对, 如 trace id, 参见 `WithContextExtractor`.
+type ContextExtractor func(ctx context.Context) []any
+
+// WithContextExtractor add extractors which are called for every output log with a non-nil ctx,
+// the extracted attrs are appended after the attrs of the ctx, see `TraceparentExtractor` and `SpanContextExtractor`.
+//
+// 添加 ctx 提取器, 每条输出的日志(ctx 不为 nil 时)都会调用, 提取的键值对追加在 ctx 上的键值对之后.
+// 参见 `TraceparentExtractor` 及 `SpanContextExtractor`.
+func WithContextExtractor(extractors ...ContextExtractor) Option {
+	return func(h *handler) {
+		h.extractors = append(h.extractors[:len(h.extractors):len(h.extractors)], extractors...)
+	}
+}
+
+// extract returns the attrs extracted from the ctx by the extractors.
+//
+// 返回提取器从 ctx 中提取的键值对.
+func extract(ctx context.Context, extractors []ContextExtractor) (attrs []any) {
+	if ctx == nil {
+		return nil
+	}
+	for _, fn := range extractors {
+		attrs = append(attrs, fn(ctx)...)
+	}
+	return attrs
+}
+
+const (
+	KeyTraceID = "trace_id" // 键: trace id
+	KeySpanID  = "span_id"  // 键: span id
+
+	TraceparentHeader = "traceparent" // W3C Trace Context 请求头
+)
+
+type traceparentKey struct{}
+
+// WithTraceparent returns a copy of ctx which carries the W3C traceparent value, such as
+// `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`, see `TraceparentExtractor`.
+//
+// 在 ctx 上设置 W3C traceparent 值, 参见 `TraceparentExtractor`.
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	return context.WithValue(ctx, traceparentKey{}, traceparent)
+}
+
+// Traceparent returns the traceparent set by `WithTraceparent`.
+//
+// 获取 `WithTraceparent` 设置的 traceparent 值.
+func Traceparent(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	s, ok := ctx.Value(traceparentKey{}).(string)
+	return s, ok
+}
+
+// TraceparentMiddleware returns a http middleware which calls `WithTraceparent` on the request ctx
+// if the request has the `traceparent` header.
+//
+// 返回一个 http 中间件, 如果请求头中有 `traceparent`, 就在请求的 ctx 上设置该值.
+func TraceparentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s := r.Header.Get(TraceparentHeader); s != "" {
+			r = r.WithContext(WithTraceparent(r.Context(), s))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TraceparentExtractor extract trace_id and span_id from the W3C traceparent value set by `WithTraceparent`,
+// nothing is extracted if the value is invalid.
+//
+// 从 `WithTraceparent` 设置的 W3C traceparent 值中提取 trace_id 及 span_id, 值无效时不提取.
+func TraceparentExtractor(ctx context.Context) []any {
+	s, ok := Traceparent(ctx)
+	if !ok {
+		return nil
+	}
+	traceID, spanID, ok := parseTraceparent(s)
+	if !ok {
+		return nil
+	}
+	return []any{KeyTraceID, traceID, KeySpanID, spanID}
+}
+
+// parseTraceparent parse the traceparent `version-traceid-parentid-flags`, see https://www.w3.org/TR/trace-context/
+//
+// 解析 traceparent 值.
+func parseTraceparent(s string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) ||
+		!isHex(parts[0]) || !isHex(parts[3]) || len(parts[3]) != 2 {
+		return "", "", false
+	}
+	traceID, spanID = parts[1], parts[2]
+	if len(traceID) != 32 || len(spanID) != 16 || !isHex(traceID) || !isHex(spanID) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// isHex reports whether s is lower case hex.
+//
+// 判断是否为小写的十六进制字符串.
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SpanContextExtractor returns an extractor which extract trace_id and span_id by fn, nothing is extracted
+// if fn returns false. fn reads the span context of the tracing library, such as the OpenTelemetry trace.SpanContext,
+// so this package does not depend on it:
+//
+//	logs.WithContextExtractor(logs.SpanContextExtractor(func(ctx context.Context) (traceID, spanID string, ok bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		if !sc.IsValid() {
+//			return "", "", false
+//		}
+//		return sc.TraceID().String(), sc.SpanID().String(), true
+//	}))
+//
+// 返回一个提取器, 通过 fn 提取 trace_id 及 span_id, fn 返回 false 时不提取.
+// fn 读取链路追踪库的 span context, 如 OpenTelemetry 的 trace.SpanContext, 因此本包不依赖这些库.
+func SpanContextExtractor(fn func(ctx context.Context) (traceID, spanID string, ok bool)) ContextExtractor {
+	return func(ctx context.Context) []any {
+		traceID, spanID, ok := fn(ctx)
+		if !ok {
+			return nil
+		}
+		return []any{KeyTraceID, traceID, KeySpanID, spanID}
+	}
+}
@@ -0,0 +1,111 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID      = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testSpanID + "-01"
+)
+
+func Test_parseTraceparent(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		ok   bool
+	}{
+		{"valid", testTraceparent, true},
+		{"future-version", "01-" + testTraceID + "-" + testSpanID + "-01-extra", true},
+		{"version-00-extra", testTraceparent + "-extra", false},
+		{"version-ff", "ff-" + testTraceID + "-" + testSpanID + "-01", false},
+		{"upper-case", "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID + "-01", false},
+		{"zero-trace-id", "00-00000000000000000000000000000000-" + testSpanID + "-01", false},
+		{"zero-span-id", "00-" + testTraceID + "-0000000000000000-01", false},
+		{"short-span-id", "00-" + testTraceID + "-00f067aa-01", false},
+		{"bad-flags", "00-" + testTraceID + "-" + testSpanID + "-1", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, spanID, ok := parseTraceparent(tt.s)
+			if ok != tt.ok || (ok && (traceID != testTraceID || spanID != testSpanID)) {
+				t.Errorf("parseTraceparent() = %v, %v, %v, want ok=%v", traceID, spanID, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTraceparentMiddleware(t *testing.T) {
+	var got string
+	h := TraceparentMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = Traceparent(r.Context())
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(TraceparentHeader, testTraceparent)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if got != testTraceparent {
+		t.Errorf("Traceparent() = %q, want %q", got, testTraceparent)
+	}
+}
+
+// spanContext is like the OpenTelemetry trace.SpanContext.
+type spanContext struct {
+	traceID traceID
+	spanID  spanID
+}
+type traceID [16]byte
+type spanID [8]byte
+
+func (t traceID) String() string        { return hex.EncodeToString(t[:]) }
+func (s spanID) String() string         { return hex.EncodeToString(s[:]) }
+func (sc spanContext) IsValid() bool    { return sc.traceID != traceID{} && sc.spanID != spanID{} }
+func (sc spanContext) TraceID() traceID { return sc.traceID }
+func (sc spanContext) SpanID() spanID   { return sc.spanID }
+
+type spanContextKey struct{}
+
+func TestWithContextExtractor(t *testing.T) {
+	var sc spanContext
+	hex.Decode(sc.traceID[:], []byte(testTraceID))
+	hex.Decode(sc.spanID[:], []byte(testSpanID))
+	spanFromContext := func(ctx context.Context) (string, string, bool) {
+		sc, _ := ctx.Value(spanContextKey{}).(spanContext)
+		if !sc.IsValid() {
+			return "", "", false
+		}
+		return sc.TraceID().String(), sc.SpanID().String(), true
+	}
+	tests := []struct {
+		name      string
+		extractor ContextExtractor
+		ctx       context.Context
+		want      bool
+	}{
+		{"traceparent", TraceparentExtractor, WithTraceparent(ctx, testTraceparent), true},
+		{"traceparent-invalid", TraceparentExtractor, WithTraceparent(ctx, "00-invalid"), false},
+		{"traceparent-none", TraceparentExtractor, ctx, false},
+		{"span-context", SpanContextExtractor(spanFromContext), context.WithValue(ctx, spanContextKey{}, sc), true},
+		{"span-context-invalid", SpanContextExtractor(spanFromContext), ctx, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			NewLogger(NewHandler(WithWriter(&buf), WithJSON(), WithContextExtractor(tt.extractor))).Info(tt.ctx, "msg")
+			var m map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+				t.Fatalf("unexpected output: %s", buf.String())
+			}
+			if got := m[KeyTraceID] == testTraceID && m[KeySpanID] == testSpanID; got != tt.want {
+				t.Errorf("unexpected output: %s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package logs
+
+import (
+	"fmt"
+)
+
+// Valuer is implemented by the types which control their log representation, like slog.LogValuer.
+// the handler replaces the attr value with the result of LogValue before output, so it is consistent in every
+// output format. LogValue may return a `Group` which is output as a group, or another Valuer which is resolved too.
+// slog.LogValuer is honoured as well when built with go1.21 or later.
+//
+//	func (u User) LogValue() any { return logs.Group{"id", u.ID, "name", u.Name} }
+//
+// 由需要控制自身日志输出形式的类型实现, 类似 slog.LogValuer.
+// 处理器在输出前将键值对的值替换为 LogValue 的返回值, 因此在所有输出格式中都是一致的.
+// LogValue 可以返回 `Group` 以分组输出, 也可以返回另一个 Valuer, 同样会被解析.
+// 使用 go1.21 及以上版本编译时, 同样支持 slog.LogValuer.
+type Valuer interface {
+	LogValue() any
+}
+
+// maxResolveDepth is the max times of resolving a value, it stops the Valuer which returns itself.
+//
+// 解析一个值的最大次数, 用于终止返回自身的 Valuer.
+const maxResolveDepth = 100
+
+// resolveSlog resolve the slog.LogValuer, it is set in slog.go since log/slog requires go1.21.
+//
+// 解析 slog.LogValuer, 由于 log/slog 需要 go1.21, 在 slog.go 中设置.
+var resolveSlog func(v any) (any, bool)
+
+// resolve returns the value of the Valuer, the Field of KindAny is unwrapped if it holds a Valuer.
+//
+// 返回 Valuer 的值. 如果 KindAny 的 Field 持有 Valuer, 则解开 Field.
+func resolve(v any) (any, bool) {
+	changed := false
+	for i := 0; i < maxResolveDepth; i++ {
+		switch x := v.(type) {
+		case Valuer:
+			v = logValue(x)
+		case Field:
+			if x.Kind != KindAny {
+				return v, changed
+			}
+			if value, ok := resolve(x.any); ok {
+				return value, true
+			}
+			return v, changed
+		default:
+			if resolveSlog == nil {
+				return v, changed
+			}
+			value, ok := resolveSlog(v)
+			if !ok {
+				return v, changed
+			}
+			v = value
+		}
+		changed = true
+	}
+	return fmt.Sprintf("!(LogValue: exceeded %d resolutions)", maxResolveDepth), true
+}
+
+// logValue calls LogValue, the panic is recovered and output as the value.
+//
+// 调用 LogValue, panic 会被恢复并作为值输出.
+func logValue(v Valuer) (value any) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("!(PANIC=LogValue method: %v)", r)
+		}
+	}()
+	return v.LogValue()
+}
+
+// resolveAttrs resolve the values of the attrs, including the values in groups.
+//
+// 解析键值对的值, 包括分组中的值.
+func resolveAttrs(attrs []any) []any {
+	attrs, _ = mapAttrs(attrs, func(_, value any) (any, bool) { return resolve(value) })
+	return attrs
+}
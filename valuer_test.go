@@ -0,0 +1,67 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+type user struct {
+	id   int
+	name string
+}
+
+func (u user) LogValue() any { return Group{"id", u.id, "name", u.name} }
+
+type userID int
+
+func (u userID) LogValue() any { return user{id: int(u), name: "alice"} }
+
+type loop struct{}
+
+func (l loop) LogValue() any { return l }
+
+type panicValuer struct{}
+
+func (panicValuer) LogValue() any { panic("boom") }
+
+func TestValuer(t *testing.T) {
+	var buf bytes.Buffer
+	u := user{id: 1, name: "alice"}
+	logger := NewLogger(NewHandler(WithWriter(&buf))).With("user", u)
+	logger.WithGroup("g").InfoKV(context.Background(), "text", "uid", userID(2), F.Any("any", u), "loop", loop{}, "panic", panicValuer{})
+	got := buf.String()
+	want := "user.id=1 user.name=alice g.uid.id=2 g.uid.name=alice g.any.id=1 g.any.name=alice " +
+		"g.loop=!(LogValue: exceeded 100 resolutions) g.panic=!(PANIC=LogValue method: boom) text\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("got= %s\nwant suffix= %s", got, want)
+	}
+
+	buf.Reset()
+	logger = NewLogger(NewHandler(WithWriter(&buf), WithJSON())).With("user", u)
+	logger.InfoKV(context.Background(), "json", "uid", userID(2))
+	if got, want := buf.String(), `"user":{"id":1,"name":"alice"},"uid":{"id":2,"name":"alice"},"msg":"json"}`; !strings.Contains(got, want) {
+		t.Errorf("got= %s\nwant= %s", got, want)
+	}
+}
+
+func Test_resolve(t *testing.T) {
+	for _, v := range []any{1, "s", nil, F.Int("k", 1), F.Any("k", 1), Group{"k", 1}} {
+		if _, changed := resolve(v); changed {
+			t.Errorf("resolve(%v) should not change", v)
+		}
+	}
+	attrs := []any{"a", 1, "g", Group{"b", 2}}
+	if got := resolveAttrs(attrs); &got[0] != &attrs[0] {
+		t.Errorf("resolveAttrs() should not copy the attrs without Valuer")
+	}
+	attrs = []any{"a", 1, "g", Group{"u", userID(1)}}
+	got := resolveAttrs(attrs)
+	if _, ok := attrs[3].(Group)[1].(userID); !ok {
+		t.Errorf("resolveAttrs() should not modify the input")
+	}
+	if got := Group(got).String(); got != "a=1 g.u.id=1 g.u.name=alice" {
+		t.Errorf("resolveAttrs() = %s", got)
+	}
+}